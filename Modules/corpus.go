@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// corpusLayers returns the raw corpus files for a module, shipped defaults first.
+// User files from --payload-dir and --corpus are layered on top in that order.
+func corpusLayers(opts Options, module, file string) ([][]byte, error) {
+	var layers [][]byte
+
+	if opts.Corpus != nil {
+		data, err := fs.ReadFile(opts.Corpus, file)
+		if err == nil {
+			layers = append(layers, data)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read embedded %s: %v", file, err)
+		}
+	}
+
+	if opts.PayloadDir != "" {
+		data, err := os.ReadFile(filepath.Join(opts.PayloadDir, file))
+		if err == nil {
+			layers = append(layers, data)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s from payload dir: %v", file, err)
+		}
+	}
+
+	if path, ok := opts.CorpusFiles[module]; ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s corpus: %v", module, err)
+		}
+		layers = append(layers, data)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no corpus found for %s (%s)", module, file)
+	}
+	return layers, nil
+}
+
+// loadCorpus decodes every corpus layer of a module as a JSON list and concatenates them
+func loadCorpus[T any](opts Options, module, file string) ([]T, error) {
+	layers, err := corpusLayers(opts, module, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []T
+	for _, data := range layers {
+		var layer []T
+		if err := json.Unmarshal(data, &layer); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		entries = append(entries, layer...)
+	}
+	return entries, nil
+}
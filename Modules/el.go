@@ -0,0 +1,41 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// ELPayload is an expression-language probe together with the output it renders to when evaluated
+type ELPayload struct {
+	Engine    string          `json:"engine"`    // Java EL, SpEL or OGNL
+	Framework string          `json:"framework"` // where the engine is usually reached, e.g. Struts 2
+	Probe     string          `json:"probe"`
+	Expected  string          `json:"expected"` // appears in the response only if the probe was evaluated
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the EL probe
+func (p ELPayload) Text() string {
+	return p.Probe
+}
+
+// probeFields exposes the fields generateProbes fills in
+func (p *ELPayload) probeFields() (probe, expected *string, encodings *[]utils.Variant) {
+	return &p.Probe, &p.Expected, &p.Encodings
+}
+
+func init() {
+	register("el", "Generate Expression Language (Java EL, SpEL, OGNL) injection probes", GenerateELPayloads)
+}
+
+// LoadELPayloads loads EL probes from the embedded el.json and any user layers
+func LoadELPayloads(opts Options) ([]ELPayload, error) {
+	return loadCorpus[ELPayload](opts, "el", "el.json")
+}
+
+// GenerateELPayloads expands the probes and their expected output with the same placeholder values.
+// Expression syntax such as ${7331*7331} is not a placeholder and is left as it is.
+func GenerateELPayloads(opts Options) ([]ELPayload, error) {
+	templates, err := LoadELPayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+	return generateProbes(opts, templates), nil
+}
@@ -0,0 +1,21 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// defaultEncodings are the chains applied when --encode is not given
+var defaultEncodings = []string{"url", "base64", "hex", "unicode"}
+
+// encoders returns the user's --encode chains, or the defaults plus any module-specific extras
+func (o Options) encoders(extra ...string) []utils.Pipeline {
+	if len(o.Encoders) > 0 {
+		return o.Encoders
+	}
+
+	var pipelines []utils.Pipeline
+	for _, spec := range append(append([]string{}, defaultEncodings...), extra...) {
+		if p, err := utils.ParsePipeline(spec); err == nil {
+			pipelines = append(pipelines, p)
+		}
+	}
+	return pipelines
+}
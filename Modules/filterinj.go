@@ -0,0 +1,64 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// FilterPayload is a raw injection into a query expression such as an LDAP filter or an XPath query
+type FilterPayload struct {
+	Category  string          `json:"category"`
+	Negated   string          `json:"negated,omitempty"` // false counterpart of the expression, used for boolean pairs
+	Payload   string          `json:"payload"`
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+	*BooleanPair
+}
+
+// Text returns the delivered form of the injection
+func (p FilterPayload) Text() string {
+	return p.Payload
+}
+
+// filterLanguage describes a query language whose injections are plain corpus expressions
+type filterLanguage struct {
+	Module string
+	File   string
+	// Escape quotes a placeholder value for use inside the expression; nil uses values as given
+	Escape func(string) string
+}
+
+// generate expands the language's corpus and applies encodings. In pairs mode only expressions
+// with a false counterpart are kept and each carries its boolean pair.
+func (l filterLanguage) generate(opts Options, pairs bool) ([]FilterPayload, error) {
+	templates, err := loadCorpus[FilterPayload](opts, l.Module, l.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var payloads []FilterPayload
+	for _, tpl := range templates {
+		if pairs && tpl.Negated == "" {
+			continue
+		}
+		joint := []string{tpl.Payload}
+		if pairs {
+			joint = append(joint, tpl.Negated)
+		}
+		for _, b := range expandJoint(opts, joint...) {
+			if l.Escape != nil {
+				escaped := make(templateBindings, len(b))
+				for name, value := range b {
+					escaped[name] = l.Escape(value)
+				}
+				b = escaped
+			}
+
+			p := tpl
+			p.Payload = b.fill(tpl.Payload)
+			p.Negated = ""
+			if pairs {
+				p.BooleanPair = newBooleanPair(p.Payload, b.fill(tpl.Negated), l.Module)
+			}
+			p.Encodings = utils.EncodeVariants(p.Payload, opts.encoders())
+			payloads = append(payloads, p)
+		}
+	}
+	return payloads, nil
+}
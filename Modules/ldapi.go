@@ -0,0 +1,30 @@
+package modules
+
+import "strings"
+
+// LDAPPayload categories: wildcard, filter-injection, attribute-probe, boolean, blind-extraction
+type LDAPPayload = FilterPayload
+
+// LDAP injection generation modes selected with --ldapi-mode
+const (
+	LDAPiModePayloads = "payloads"
+	LDAPiModePairs    = "pairs"
+)
+
+// LDAPiModes lists the values accepted by --ldapi-mode
+var LDAPiModes = []string{LDAPiModePayloads, LDAPiModePairs}
+
+// ldapEscaper escapes the filter metacharacters of RFC 4515, so a value such as a * in the
+// charset is matched literally instead of acting as a wildcard
+var ldapEscaper = strings.NewReplacer(`\`, `\5c`, "*", `\2a`, "(", `\28`, ")", `\29`, "\x00", `\00`)
+
+var ldapLanguage = filterLanguage{Module: "ldapi", File: "ldap.json", Escape: ldapEscaper.Replace}
+
+func init() {
+	register("ldapi", "Generate LDAP Injection payloads", GenerateLDAPPayloads)
+}
+
+// GenerateLDAPPayloads expands the filter injections in ldap.json and applies encodings
+func GenerateLDAPPayloads(opts Options) ([]LDAPPayload, error) {
+	return ldapLanguage.generate(opts, opts.LDAPiMode == LDAPiModePairs)
+}
@@ -0,0 +1,270 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// NoSQLPayload is a MongoDB-style query injection rendered for one kind of input
+type NoSQLPayload struct {
+	Technique string          `json:"technique"` // operator, javascript or time-based
+	Operator  string          `json:"operator"`  // $ne, $gt, $regex, $where, ...
+	Target    string          `json:"target"`    // json, form or query
+	Payload   string          `json:"payload"`
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+	*BooleanPair
+}
+
+// Text returns the delivered form of the NoSQL payload
+func (p NoSQLPayload) Text() string {
+	return p.Payload
+}
+
+// NoSQLTemplate is a corpus entry: the injected query as a JSON body, with its false counterpart for boolean pairs
+type NoSQLTemplate struct {
+	Technique string          `json:"technique"`
+	Operator  string          `json:"operator"`
+	Body      json.RawMessage `json:"body"`
+	Negated   json.RawMessage `json:"negated,omitempty"`
+}
+
+// NoSQL generation modes selected with --nosql-mode
+const (
+	NoSQLModePayloads = "payloads"
+	NoSQLModePairs    = "pairs"
+)
+
+// NoSQLModes lists the values accepted by --nosql-mode
+var NoSQLModes = []string{NoSQLModePayloads, NoSQLModePairs}
+
+// NoSQL input kinds a payload is rendered for
+const (
+	NoSQLTargetJSON  = "json"
+	NoSQLTargetForm  = "form"
+	NoSQLTargetQuery = "query"
+)
+
+var noSQLTargets = []string{NoSQLTargetJSON, NoSQLTargetForm, NoSQLTargetQuery}
+
+func init() {
+	register("nosql", "Generate NoSQL (MongoDB) Injection payloads", GenerateNoSQLPayloads)
+}
+
+// LoadNoSQLPayloads loads NoSQL query templates from the embedded nosql.json and any user layers
+func LoadNoSQLPayloads(opts Options) ([]NoSQLTemplate, error) {
+	return loadCorpus[NoSQLTemplate](opts, "nosql", "nosql.json")
+}
+
+// renderNoSQL writes a JSON query body for the given input kind: compact JSON, or the bracket
+// notation (user[$ne]=x) that Express/qs and PHP turn back into nested objects. Query strings
+// percent-encode the brackets and spaces as RFC 3986 requires; form bodies keep them readable.
+func renderNoSQL(body []byte, target string) (string, error) {
+	if target == NoSQLTargetJSON {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, body); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var pairs []string
+	if err := flattenJSON(dec, "", func(key, value string) {
+		value = url.QueryEscape(value)
+		if target == NoSQLTargetQuery {
+			key = strings.NewReplacer("[", "%5B", "]", "%5D").Replace(key)
+			value = strings.ReplaceAll(value, "+", "%20")
+		}
+		pairs = append(pairs, key+"="+value)
+	}); err != nil {
+		return "", err
+	}
+	return strings.Join(pairs, "&"), nil
+}
+
+// flattenJSON walks the next JSON value in key order and emits one bracket-notation key per leaf
+func flattenJSON(dec *json.Decoder, prefix string, emit func(key, value string)) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := keyTok.(string)
+				if prefix != "" {
+					key = prefix + "[" + key + "]"
+				}
+				if err := flattenJSON(dec, key, emit); err != nil {
+					return err
+				}
+			}
+		case '[':
+			empty := true
+			for dec.More() {
+				empty = false
+				if err := flattenJSON(dec, prefix+"[]", emit); err != nil {
+					return err
+				}
+			}
+			if empty {
+				emit(prefix+"[]", "")
+			}
+		}
+		// consume the closing delimiter
+		if _, err := dec.Token(); err != nil && err != io.EOF {
+			return err
+		}
+	case string:
+		emit(prefix, t)
+	case nil:
+		emit(prefix, "")
+	default:
+		emit(prefix, fmt.Sprint(t))
+	}
+	return nil
+}
+
+// fillNoSQL substitutes placeholder values into the keys and string values of a JSON body and
+// re-encodes them, so values containing quotes or backslashes keep the body valid. Key order is kept.
+func fillNoSQL(body []byte, bindings templateBindings) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := fillJSON(dec, &buf, bindings); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fillJSON copies the next JSON value from dec to buf, rendering every string with the bindings
+func fillJSON(dec *json.Decoder, buf *bytes.Buffer, bindings templateBindings) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		buf.WriteRune(rune(t))
+		for first := true; dec.More(); first = false {
+			if !first {
+				buf.WriteByte(',')
+			}
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				writeJSONString(buf, bindings.fill(key.(string)))
+				buf.WriteByte(':')
+			}
+			if err := fillJSON(dec, buf, bindings); err != nil {
+				return err
+			}
+		}
+		end, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		buf.WriteRune(rune(end.(json.Delim)))
+	case string:
+		writeJSONString(buf, bindings.fill(t))
+	case nil:
+		buf.WriteString("null")
+	default:
+		fmt.Fprint(buf, t)
+	}
+	return nil
+}
+
+// writeJSONString writes s as a JSON string. <, > and & stay as they are, as in the corpus, while
+// unprintable characters such as \uffff are escaped so the output stays readable.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	var enc bytes.Buffer
+	e := json.NewEncoder(&enc)
+	e.SetEscapeHTML(false)
+	e.Encode(s)
+	for _, r := range strings.TrimSuffix(enc.String(), "\n") {
+		if r < utf8.RuneSelf || unicode.IsPrint(r) {
+			buf.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			fmt.Fprintf(buf, `\u%04x\u%04x`, r1, r2)
+		} else {
+			fmt.Fprintf(buf, `\u%04x`, r)
+		}
+	}
+}
+
+// GenerateNoSQLPayloads expands every template and renders it as a JSON body, a form body and a query string.
+// In pairs mode only templates with a false counterpart are kept and each payload carries its boolean pair.
+func GenerateNoSQLPayloads(opts Options) ([]NoSQLPayload, error) {
+	templates, err := LoadNoSQLPayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var payloads []NoSQLPayload
+	for _, tpl := range templates {
+		pairs := opts.NoSQLMode == NoSQLModePairs
+		if pairs && len(tpl.Negated) == 0 {
+			continue
+		}
+		// placeholders are expanded over the raw JSON only to choose their values; the values
+		// are then filled into the decoded strings so they cannot break the JSON syntax
+		joint := []string{string(tpl.Body)}
+		if pairs {
+			joint = append(joint, string(tpl.Negated))
+		}
+		for _, b := range expandJoint(opts, joint...) {
+			body, err := fillNoSQL(tpl.Body, b)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NoSQL template %s: %v", tpl.Body, err)
+			}
+			var negated []byte
+			if pairs {
+				if negated, err = fillNoSQL(tpl.Negated, b); err != nil {
+					return nil, fmt.Errorf("invalid NoSQL template %s: %v", tpl.Negated, err)
+				}
+			}
+			for _, target := range noSQLTargets {
+				text, err := renderNoSQL(body, target)
+				if err != nil {
+					return nil, fmt.Errorf("invalid NoSQL template %s: %v", tpl.Body, err)
+				}
+				p := NoSQLPayload{
+					Technique: tpl.Technique,
+					Operator:  tpl.Operator,
+					Target:    target,
+					Payload:   text,
+					Encodings: utils.EncodeVariants(text, opts.encoders()),
+				}
+				if pairs {
+					falseText, err := renderNoSQL(negated, target)
+					if err != nil {
+						return nil, fmt.Errorf("invalid NoSQL template %s: %v", tpl.Negated, err)
+					}
+					p.BooleanPair = newBooleanPair(text, falseText, target)
+				}
+				payloads = append(payloads, p)
+			}
+		}
+	}
+	return payloads, nil
+}
@@ -0,0 +1,14 @@
+package modules
+
+// obfuscationAttempts is how many random obfuscations are tried before falling back to the raw payload
+const obfuscationAttempts = 3
+
+// obfuscateChecked obfuscates a payload and keeps the result only if the validator accepts it
+func obfuscateChecked(raw string, obfuscate func(string) string, validate func(original, obfuscated string) error) string {
+	for i := 0; i < obfuscationAttempts; i++ {
+		if out := obfuscate(raw); validate(raw, out) == nil {
+			return out
+		}
+	}
+	return raw
+}
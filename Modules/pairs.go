@@ -0,0 +1,30 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// BooleanPair is a matched true/false payload pair for differential (blind) testing;
+// the target is vulnerable if the two responses differ
+type BooleanPair struct {
+	PairID       string `json:"pair_id"`
+	TrueVariant  string `json:"true_variant"`
+	FalseVariant string `json:"false_variant"`
+}
+
+// Paired is implemented by payloads that may carry a BooleanPair
+type Paired interface {
+	Pair() *BooleanPair
+}
+
+// Pair returns the pair itself, or nil for payloads generated without one
+func (b *BooleanPair) Pair() *BooleanPair {
+	return b
+}
+
+// newBooleanPair builds a pair whose ID is derived from its variants and the given context
+func newBooleanPair(trueVariant, falseVariant string, context ...string) *BooleanPair {
+	return &BooleanPair{
+		PairID:       utils.ShortID(append(context, trueVariant, falseVariant)...),
+		TrueVariant:  trueVariant,
+		FalseVariant: falseVariant,
+	}
+}
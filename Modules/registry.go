@@ -0,0 +1,130 @@
+package modules
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"sort"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// Payload is implemented by every payload type a module produces
+type Payload interface {
+	// Text returns the payload as it should be delivered, used for line-based output and the clipboard
+	Text() string
+}
+
+// File is implemented by payloads delivered as a file; an empty name means the payload has no file
+type File interface {
+	File() (name string, content []byte)
+}
+
+// Options carries the CLI settings shared by every module
+type Options struct {
+	// Corpus holds the shipped corpora, normally embedded into the binary
+	Corpus fs.FS
+	// PayloadDir is searched for user corpora named like the shipped ones (sqli.json, cmd.json, ...)
+	PayloadDir string
+	// CorpusFiles maps a module name to an extra user corpus file
+	CorpusFiles map[string]string
+	// Count is the number of values substituted for {n} placeholders (1..Count)
+	Count int
+	// Vars overrides the placeholder values of DefaultVars, e.g. marker or sleep_seconds
+	Vars utils.Vars
+	// Encoders are the chains every payload is encoded with; empty means the module defaults
+	Encoders []utils.Pipeline
+	// XSSContexts are the injection contexts XSS payloads are built for; empty means html
+	XSSContexts []string
+	// DBMS restricts SQLi payloads to these engines; engine-agnostic payloads are always kept
+	DBMS []string
+	// SQLiContexts are the injection points SQLi payloads are built for; empty means single-quote
+	SQLiContexts []string
+	// SQLiMode selects what the sqli module generates: corpus payloads or union probes
+	SQLiMode string
+	// MaxColumns is the widest query union probes go up to
+	MaxColumns int
+	// Shells restricts CMDi payloads to these shells; empty means every shell
+	Shells []string
+	// CMDiMode selects what the cmdi module generates: corpus commands or blind detection payloads
+	CMDiMode string
+	// Depth is the deepest ../ sequence traversal payloads go up to
+	Depth int
+	// NoSQLMode selects what the nosql module generates: operator and JavaScript payloads or boolean pairs
+	NoSQLMode string
+	// LDAPiMode selects what the ldapi module generates: filter injections or boolean pairs
+	LDAPiMode string
+	// XPathiMode selects what the xpathi module generates: XPath injections or boolean pairs
+	XPathiMode string
+	// SSRFTarget is the host[:port] SSRF payloads point at; empty means the local canary
+	SSRFTarget string
+	// AllowedHost is the host an allowlist or redirect check trusts, used to build parser-confusion URLs
+	AllowedHost string
+	// AttackerHost is the host open-redirect payloads send the victim to
+	AttackerHost string
+	// Seed drives every random choice; each module starts its own source from it
+	Seed int64
+}
+
+// rng returns a fresh random source for one module run, so a module's output depends
+// only on the seed and not on which other modules ran before it
+func (o Options) rng() *rand.Rand {
+	return utils.NewRand(o.Seed)
+}
+
+// Generator produces the payloads of a single module
+type Generator func(opts Options) ([]Payload, error)
+
+// Module describes a vulnerability class that can be selected from the CLI
+type Module struct {
+	Name        string
+	Description string
+	Generate    Generator
+}
+
+var registry = map[string]Module{}
+
+// Register adds a module to the registry; it panics on duplicate names
+func Register(m Module) {
+	if _, exists := registry[m.Name]; exists {
+		panic(fmt.Sprintf("modules: duplicate module %q", m.Name))
+	}
+	registry[m.Name] = m
+}
+
+// register adds a module whose generator returns a concrete payload type
+func register[T Payload](name, description string, generate func(Options) ([]T, error)) {
+	Register(Module{
+		Name:        name,
+		Description: description,
+		Generate: func(opts Options) ([]Payload, error) {
+			payloads, err := generate(opts)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]Payload, 0, len(payloads))
+			for _, p := range payloads {
+				out = append(out, p)
+			}
+			return out, nil
+		},
+	})
+}
+
+// Lookup returns the registered module with the given name
+func Lookup(name string) (Module, bool) {
+	m, ok := registry[name]
+	return m, ok
+}
+
+// All returns every registered module sorted by name
+func All() []Module {
+	var mods []Module
+	for _, m := range registry {
+		mods = append(mods, m)
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Name < mods[j].Name
+	})
+	return mods
+}
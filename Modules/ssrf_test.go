@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// inetAton reads an IPv4 address the way the C library's inet_aton does, independently of the
+// generator: one to four parts in decimal, octal (leading 0) or hex (0x), the last part filling
+// the remaining bytes
+func inetAton(s string) (net.IP, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return nil, false
+	}
+	var n uint64
+	for i, part := range parts {
+		v, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return nil, false
+		}
+		if i < len(parts)-1 {
+			if v > 0xff {
+				return nil, false
+			}
+			n |= v << (24 - 8*i)
+			continue
+		}
+		if v >= 1<<(8*(4-i)) {
+			return nil, false
+		}
+		n |= v
+	}
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)), true
+}
+
+// resolveHost returns the address a numeric host refers to, or the normalised name otherwise
+func resolveHost(host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		// ::a.b.c.d is the deprecated IPv4-compatible form
+		if ip.To4() == nil && ip[:12].Equal(net.IPv6zero[:12]) {
+			return net.IP(ip[12:]).String()
+		}
+		return ip.String()
+	}
+	if ip, ok := inetAton(host); ok {
+		return ip.String()
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// TestSSRFURLsReachServer sends every generated HTTP URL through a client whose dialer connects
+// to a local server, checking that each host spelling refers to the intended address and that
+// the path and required header arrive intact
+func TestSSRFURLsReachServer(t *testing.T) {
+	var mu sync.Mutex
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = r
+	}))
+	defer srv.Close()
+
+	var dialed string
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			dialed = host
+			var d net.Dialer
+			return d.DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+		DisableKeepAlives: true,
+	}}
+
+	target := srv.Listener.Addr().String()
+	payloads, err := GenerateSSRFPayloads(Options{SSRFTarget: target})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetHost, _, _ := net.SplitHostPort(target)
+	metadataHosts := map[string]bool{}
+	for _, m := range metadataEndpoints {
+		metadataHosts[resolveHost(m.Host)] = true
+	}
+
+	checked := map[string]int{}
+	for _, p := range payloads {
+		switch p.Category {
+		case "ip-encoding", "metadata", "metadata-address":
+		default:
+			continue
+		}
+
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			t.Errorf("%s/%s: unparseable URL %q: %v", p.Category, p.Technique, p.URL, err)
+			continue
+		}
+		req, err := http.NewRequest(http.MethodGet, p.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, value, _ := strings.Cut(p.Header, ": ")
+		if name != "" {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("%s/%s: %s: %v", p.Category, p.Technique, p.URL, err)
+			continue
+		}
+		resp.Body.Close()
+
+		h := resolveHost(dialed)
+		if p.Category == "metadata-address" && !metadataHosts[h] {
+			t.Errorf("%s/%s: %s dialed %s (%s), not a metadata address", p.Category, p.Technique, p.URL, dialed, h)
+		}
+		if p.Category != "metadata-address" && h != targetHost {
+			t.Errorf("%s/%s: %s dialed %s (%s), want %s", p.Category, p.Technique, p.URL, dialed, h, targetHost)
+		}
+		mu.Lock()
+		if got.URL.RequestURI() != u.RequestURI() {
+			t.Errorf("%s/%s: server got %q, want %q", p.Category, p.Technique, got.URL.RequestURI(), u.RequestURI())
+		}
+		if name != "" && got.Header.Get(name) != value {
+			t.Errorf("%s/%s: server got %s %q, want %q", p.Category, p.Technique, name, got.Header.Get(name), value)
+		}
+		mu.Unlock()
+		checked[p.Category]++
+	}
+
+	for _, category := range []string{"ip-encoding", "metadata", "metadata-address"} {
+		if checked[category] == 0 {
+			t.Errorf("no %s URLs were sent", category)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package modules
+
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+
+// SSTIPayload is a template-engine probe together with the output it renders to when evaluated
+type SSTIPayload struct {
+	Engine    string          `json:"engine"`
+	Language  string          `json:"language"`
+	Probe     string          `json:"probe"`
+	Expected  string          `json:"expected"` // appears in the response only if the probe was evaluated
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the SSTI probe
+func (p SSTIPayload) Text() string {
+	return p.Probe
+}
+
+// probeFields exposes the fields generateProbes fills in
+func (p *SSTIPayload) probeFields() (probe, expected *string, encodings *[]utils.Variant) {
+	return &p.Probe, &p.Expected, &p.Encodings
+}
+
+func init() {
+	register("ssti", "Generate Server-Side Template Injection probes", GenerateSSTIPayloads)
+}
+
+// LoadSSTIPayloads loads SSTI probes from the embedded ssti.json and any user layers
+func LoadSSTIPayloads(opts Options) ([]SSTIPayload, error) {
+	return loadCorpus[SSTIPayload](opts, "ssti", "ssti.json")
+}
+
+// GenerateSSTIPayloads expands the probes and their expected output with the same placeholder values.
+// Engine syntax such as {{7*7}} is not a placeholder and is left as it is.
+func GenerateSSTIPayloads(opts Options) ([]SSTIPayload, error) {
+	templates, err := LoadSSTIPayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+	return generateProbes(opts, templates), nil
+}
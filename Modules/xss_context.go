@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// xssContext describes where the reflected input lands and how to escape from it
+type xssContext struct {
+	Name string
+	// Breakout closes the surrounding construct so an HTML vector can follow
+	Breakout string
+	// Suffix reopens what the breakout closed, to keep the rest of the page parsing
+	Suffix string
+	// HTML reports whether the corpus' html vectors are usable after the breakout
+	HTML bool
+}
+
+// xssContexts lists the supported injection contexts in the order they are generated
+var xssContexts = []xssContext{
+	{Name: "html", HTML: true},
+	{Name: "attr-dq", Breakout: `">`, HTML: true},
+	{Name: "attr-sq", Breakout: `'>`, HTML: true},
+	{Name: "attr-unquoted", Breakout: `>`, HTML: true},
+	{Name: "js-string", Breakout: `</script>`, HTML: true},
+	{Name: "js-template", Breakout: `</script>`, HTML: true},
+	{Name: "url"},
+	{Name: "css", Breakout: `</style>`, HTML: true},
+	{Name: "html-comment", Breakout: `-->`, Suffix: `<!--`, HTML: true},
+}
+
+// XSSContextNames returns the names accepted by --context
+func XSSContextNames() []string {
+	var names []string
+	for _, c := range xssContexts {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// ParseXSSContexts parses a comma-separated --context value; "all" selects every context
+func ParseXSSContexts(spec string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "all" {
+			return XSSContextNames(), nil
+		}
+		if _, ok := lookupXSSContext(name); !ok {
+			return nil, fmt.Errorf("unknown XSS context %q (available: %s)", name, strings.Join(XSSContextNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func lookupXSSContext(name string) (xssContext, bool) {
+	for _, c := range xssContexts {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return xssContext{}, false
+}
+
+// xssContexts returns the contexts selected for this run; html when none were given
+func (o Options) xssContexts() []xssContext {
+	names := o.XSSContexts
+	if len(names) == 0 {
+		names = []string{"html"}
+	}
+
+	var contexts []xssContext
+	for _, name := range names {
+		if c, ok := lookupXSSContext(name); ok {
+			contexts = append(contexts, c)
+		}
+	}
+	return contexts
+}
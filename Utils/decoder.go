@@ -0,0 +1,381 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxDecodeDepth bounds how many layers Analyze will peel off
+const maxDecodeDepth = 16
+
+// Decoder reverses the encoder registered under the same name
+type Decoder interface {
+	Name() string
+	Decode(input string) (string, error)
+	// Detect reports whether the input looks like it was produced by the matching encoder
+	Detect(input string) bool
+}
+
+// decoderFunc adapts a decode and a detect function to the Decoder interface
+type decoderFunc struct {
+	name   string
+	decode func(string) (string, error)
+	detect func(string) bool
+}
+
+func (d decoderFunc) Name() string                        { return d.name }
+func (d decoderFunc) Decode(input string) (string, error) { return d.decode(input) }
+func (d decoderFunc) Detect(input string) bool            { return d.detect(input) }
+
+var (
+	decoders = map[string]Decoder{}
+	// detectOrder lists decoders from most to least specific for auto-detection
+	detectOrder []string
+)
+
+var (
+	percentPattern = regexp.MustCompile(`%[0-9A-Fa-f]{2}`)
+	base64Pattern  = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+	hexPattern     = regexp.MustCompile(`^(\\x[0-9A-Fa-f]{2})+$`)
+	unicodePattern = regexp.MustCompile(`^(\\u[0-9A-Fa-f]{4})+$`)
+	cmdiPattern    = regexp.MustCompile(`%(3B|26|7C|60|24%28|29)`)
+
+	htmlDecPadPattern = regexp.MustCompile(`&#0\d+;`)
+	htmlDecPattern    = regexp.MustCompile(`&#\d+;`)
+	htmlHexPadPattern = regexp.MustCompile(`&#[xX]0[0-9A-Fa-f]+;`)
+	htmlHexPattern    = regexp.MustCompile(`&#[xX][0-9A-Fa-f]+;`)
+	jsUnicodePattern  = regexp.MustCompile(`\\u\{[0-9A-Fa-f]{1,6}\}`)
+	cssPattern        = regexp.MustCompile(`^(\\[0-9A-Fa-f]{6})+$`)
+	cssEscapePattern  = regexp.MustCompile(`\\([0-9A-Fa-f]{1,6}) ?`)
+	octalPattern      = regexp.MustCompile(`^(\\[0-7]{3})+$`)
+	overlongPattern   = regexp.MustCompile(`%[cC][01]%[89abAB][0-9A-Fa-f]`)
+	doubleURLPattern  = regexp.MustCompile(`%25[0-9A-Fa-f]{2}`)
+	fullURLPattern    = regexp.MustCompile(`^(%[0-9A-Fa-f]{2})+$`)
+)
+
+func init() {
+	RegisterDecoder(decoderFunc{"hex", DecodeHex, hexPattern.MatchString})
+	RegisterDecoder(decoderFunc{"unicode", DecodeUnicode, unicodePattern.MatchString})
+	RegisterDecoder(decoderFunc{"css", DecodeCSS, cssPattern.MatchString})
+	RegisterDecoder(decoderFunc{"octal", DecodeOctal, octalPattern.MatchString})
+	RegisterDecoder(decoderFunc{"html-dec-pad", DecodeHTML, htmlDecPadPattern.MatchString})
+	RegisterDecoder(decoderFunc{"html-dec", DecodeHTML, htmlDecPattern.MatchString})
+	RegisterDecoder(decoderFunc{"html-hex-pad", DecodeHTML, htmlHexPadPattern.MatchString})
+	RegisterDecoder(decoderFunc{"html-hex", DecodeHTML, htmlHexPattern.MatchString})
+	RegisterDecoder(decoderFunc{"js-unicode", DecodeJSUnicode, jsUnicodePattern.MatchString})
+	// js-hex output is indistinguishable from hex/unicode, so it is only used for explicit chains
+	RegisterDecoder(decoderFunc{"js-hex", DecodeJSHex, func(string) bool { return false }})
+	RegisterDecoder(decoderFunc{"utf8-overlong", DecodeOverlongUTF8, overlongPattern.MatchString})
+	RegisterDecoder(decoderFunc{"url-double", DecodeDoubleURL, doubleURLPattern.MatchString})
+	RegisterDecoder(decoderFunc{"url-full", DecodeURL, fullURLPattern.MatchString})
+	RegisterDecoder(decoderFunc{"url", DecodeURL, percentPattern.MatchString})
+	RegisterDecoder(decoderFunc{"base64", DecodeBase64, looksLikeBase64})
+	RegisterDecoder(decoderFunc{"utf16le", DecodeUTF16LE, looksLikeUTF16LE})
+	RegisterDecoder(decoderFunc{"utf16be", DecodeUTF16BE, looksLikeUTF16BE})
+	RegisterDecoder(decoderFunc{"cmdi", DecodeCMDi, cmdiPattern.MatchString})
+}
+
+// RegisterDecoder makes a decoder available by name; registration order is the auto-detection order
+func RegisterDecoder(d Decoder) {
+	if _, exists := decoders[d.Name()]; !exists {
+		detectOrder = append(detectOrder, d.Name())
+	}
+	decoders[d.Name()] = d
+}
+
+// LookupDecoder returns the registered decoder with the given name
+func LookupDecoder(name string) (Decoder, bool) {
+	d, ok := decoders[name]
+	return d, ok
+}
+
+// DecoderNames returns the names of all registered decoders, sorted
+func DecoderNames() []string {
+	var names []string
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecodeURL reverses EncodeURL
+func DecodeURL(input string) (string, error) {
+	return url.QueryUnescape(input)
+}
+
+// DecodeBase64 reverses EncodeBase64
+func DecodeBase64(input string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(strings.TrimSpace(input))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// DecodeHex reverses EncodeHex; every \xNN escape is read as one byte
+func DecodeHex(input string) (string, error) {
+	var out []byte
+	for i := 0; i < len(input); {
+		if strings.HasPrefix(input[i:], `\x`) && i+4 <= len(input) {
+			if b, err := strconv.ParseUint(input[i+2:i+4], 16, 8); err == nil {
+				out = append(out, byte(b))
+				i += 4
+				continue
+			}
+		}
+		out = append(out, input[i])
+		i++
+	}
+	return string(out), nil
+}
+
+// DecodeUnicode reverses EncodeUnicode; \uNNNN surrogate pairs are joined
+func DecodeUnicode(input string) (string, error) {
+	var units []uint16
+	var out strings.Builder
+	flush := func() {
+		out.WriteString(string(utf16.Decode(units)))
+		units = units[:0]
+	}
+	for i := 0; i < len(input); {
+		if strings.HasPrefix(input[i:], `\u`) && i+6 <= len(input) {
+			if u, err := strconv.ParseUint(input[i+2:i+6], 16, 16); err == nil {
+				units = append(units, uint16(u))
+				i += 6
+				continue
+			}
+		}
+		flush()
+		out.WriteByte(input[i])
+		i++
+	}
+	flush()
+	return out.String(), nil
+}
+
+// DecodeCMDi reverses EncodeCMDi, leaving every other percent escape alone
+func DecodeCMDi(input string) (string, error) {
+	replacer := strings.NewReplacer(
+		"%3B", ";",
+		"%26", "&",
+		"%7C", "|",
+		"%60", "`",
+		"%24%28", "$(",
+		"%29", ")",
+	)
+	return replacer.Replace(input), nil
+}
+
+// DecodeHTML reverses all four HTML character reference encoders
+func DecodeHTML(input string) (string, error) {
+	return html.UnescapeString(input), nil
+}
+
+// DecodeJSHex reverses EncodeJSHex (\xNN and \uNNNN escapes)
+func DecodeJSHex(input string) (string, error) {
+	out, _ := DecodeUnicode(input)
+	var result strings.Builder
+	for i := 0; i < len(out); {
+		if strings.HasPrefix(out[i:], `\x`) && i+4 <= len(out) {
+			if b, err := strconv.ParseUint(out[i+2:i+4], 16, 8); err == nil {
+				result.WriteRune(rune(b))
+				i += 4
+				continue
+			}
+		}
+		result.WriteByte(out[i])
+		i++
+	}
+	return result.String(), nil
+}
+
+// DecodeJSUnicode reverses EncodeJSUnicode (\u{3c})
+func DecodeJSUnicode(input string) (string, error) {
+	return jsUnicodePattern.ReplaceAllStringFunc(input, func(m string) string {
+		r, _ := strconv.ParseUint(m[3:len(m)-1], 16, 32)
+		return string(rune(r))
+	}), nil
+}
+
+// DecodeCSS reverses CSS hex escapes, with or without the terminating space
+func DecodeCSS(input string) (string, error) {
+	return cssEscapePattern.ReplaceAllStringFunc(input, func(m string) string {
+		r, _ := strconv.ParseUint(strings.TrimSpace(m)[1:], 16, 32)
+		return string(rune(r))
+	}), nil
+}
+
+// DecodeDoubleURL reverses EncodeDoubleURL
+func DecodeDoubleURL(input string) (string, error) {
+	once, err := url.QueryUnescape(input)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(once)
+}
+
+// DecodeOverlongUTF8 folds two-byte overlong sequences back to ASCII, then URL-decodes the rest
+func DecodeOverlongUTF8(input string) (string, error) {
+	raw, err := url.PathUnescape(input)
+	if err != nil {
+		return "", err
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if (raw[i] == 0xC0 || raw[i] == 0xC1) && i+1 < len(raw) && raw[i+1]&0xC0 == 0x80 {
+			out = append(out, (raw[i]&0x1F)<<6|raw[i+1]&0x3F)
+			i++
+			continue
+		}
+		out = append(out, raw[i])
+	}
+	return string(out), nil
+}
+
+// DecodeUTF16LE reverses EncodeUTF16LE
+func DecodeUTF16LE(input string) (string, error) {
+	return decodeUTF16(input, binary.LittleEndian)
+}
+
+// DecodeUTF16BE reverses EncodeUTF16BE
+func DecodeUTF16BE(input string) (string, error) {
+	return decodeUTF16(input, binary.BigEndian)
+}
+
+func decodeUTF16(input string, order binary.ByteOrder) (string, error) {
+	if len(input)%2 != 0 {
+		return "", fmt.Errorf("odd byte count %d", len(input))
+	}
+	units := make([]uint16, len(input)/2)
+	for i := range units {
+		units[i] = order.Uint16([]byte(input[2*i:]))
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// DecodeOctal reverses EncodeOctal; every \NNN escape is read as one byte
+func DecodeOctal(input string) (string, error) {
+	var out []byte
+	for i := 0; i < len(input); {
+		if input[i] == '\\' && i+4 <= len(input) {
+			if b, err := strconv.ParseUint(input[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(b))
+				i += 4
+				continue
+			}
+		}
+		out = append(out, input[i])
+		i++
+	}
+	return string(out), nil
+}
+
+// looksLikeBase64 accepts only padded base64 that decodes to printable text (or UTF-16 text)
+func looksLikeBase64(input string) bool {
+	input = strings.TrimSpace(input)
+	if len(input) < 4 || len(input)%4 != 0 || !base64Pattern.MatchString(input) {
+		return false
+	}
+	out, err := DecodeBase64(input)
+	return err == nil && (isPrintable(out) || looksLikeUTF16LE(out) || looksLikeUTF16BE(out))
+}
+
+// looksLikeUTF16LE reports whether the input is ASCII-range text stored as UTF-16LE
+func looksLikeUTF16LE(input string) bool {
+	return looksLikeUTF16(input, 1)
+}
+
+// looksLikeUTF16BE reports whether the input is ASCII-range text stored as UTF-16BE
+func looksLikeUTF16BE(input string) bool {
+	return looksLikeUTF16(input, 0)
+}
+
+// looksLikeUTF16 checks that every high byte (at offset zero within each unit) is NUL
+func looksLikeUTF16(input string, zero int) bool {
+	if len(input) < 2 || len(input)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(input); i += 2 {
+		if input[i+zero] != 0 || input[i+1-zero] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isPrintable reports whether s is valid UTF-8 made of printable characters and whitespace
+func isPrintable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeStep records one layer removed from an encoded string
+type DecodeStep struct {
+	Decoder string `json:"decoder"`
+	Output  string `json:"output"`
+}
+
+// DecodeChain reverses an encoder chain such as "url|base64|url", undoing the last encoder first
+func DecodeChain(spec, input string) ([]DecodeStep, error) {
+	names := strings.Split(spec, "|")
+	var steps []DecodeStep
+	for i := len(names) - 1; i >= 0; i-- {
+		name := strings.TrimSpace(names[i])
+		d, ok := LookupDecoder(name)
+		if !ok {
+			return steps, fmt.Errorf("unknown decoder %q (available: %s)", name, strings.Join(DecoderNames(), ", "))
+		}
+		out, err := d.Decode(input)
+		if err != nil {
+			return steps, fmt.Errorf("%s decode failed: %v", name, err)
+		}
+		steps = append(steps, DecodeStep{Decoder: name, Output: out})
+		input = out
+	}
+	return steps, nil
+}
+
+// Analyze peels off layered encodings by repeatedly applying the first decoder that
+// recognises the input and changes it, returning every unwrapping step.
+func Analyze(input string) []DecodeStep {
+	var steps []DecodeStep
+	for depth := 0; depth < maxDecodeDepth; depth++ {
+		decoded := false
+		for _, name := range detectOrder {
+			d := decoders[name]
+			if !d.Detect(input) {
+				continue
+			}
+			out, err := d.Decode(input)
+			if err != nil || out == input {
+				continue
+			}
+			steps = append(steps, DecodeStep{Decoder: name, Output: out})
+			input = out
+			decoded = true
+			break
+		}
+		if !decoded {
+			break
+		}
+	}
+	return steps
+}
@@ -2,11 +2,122 @@ package utils
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"unicode/utf16"
 )
 
+// Encoder transforms a payload into an alternate representation
+type Encoder interface {
+	Name() string
+	Encode(input string) string
+}
+
+// encoderFunc adapts a plain function to the Encoder interface
+type encoderFunc struct {
+	name string
+	fn   func(string) string
+}
+
+func (e encoderFunc) Name() string               { return e.name }
+func (e encoderFunc) Encode(input string) string { return e.fn(input) }
+
+var encoders = map[string]Encoder{}
+
+func init() {
+	RegisterEncoder(encoderFunc{"url", EncodeURL})
+	RegisterEncoder(encoderFunc{"base64", EncodeBase64})
+	RegisterEncoder(encoderFunc{"hex", EncodeHex})
+	RegisterEncoder(encoderFunc{"unicode", EncodeUnicode})
+	RegisterEncoder(encoderFunc{"cmdi", EncodeCMDi})
+	RegisterEncoder(encoderFunc{"html-dec", EncodeHTMLDecimal})
+	RegisterEncoder(encoderFunc{"html-dec-pad", EncodeHTMLDecimalPadded})
+	RegisterEncoder(encoderFunc{"html-hex", EncodeHTMLHex})
+	RegisterEncoder(encoderFunc{"html-hex-pad", EncodeHTMLHexPadded})
+	RegisterEncoder(encoderFunc{"js-hex", EncodeJSHex})
+	RegisterEncoder(encoderFunc{"js-unicode", EncodeJSUnicode})
+	RegisterEncoder(encoderFunc{"css", EncodeCSS})
+	RegisterEncoder(encoderFunc{"url-double", EncodeDoubleURL})
+	RegisterEncoder(encoderFunc{"url-full", EncodeFullURL})
+	RegisterEncoder(encoderFunc{"utf8-overlong", EncodeOverlongUTF8})
+	RegisterEncoder(encoderFunc{"utf16le", EncodeUTF16LE})
+	RegisterEncoder(encoderFunc{"utf16be", EncodeUTF16BE})
+	RegisterEncoder(encoderFunc{"octal", EncodeOctal})
+}
+
+// RegisterEncoder makes an encoder available to pipelines under its name
+func RegisterEncoder(e Encoder) {
+	encoders[e.Name()] = e
+}
+
+// LookupEncoder returns the registered encoder with the given name
+func LookupEncoder(name string) (Encoder, bool) {
+	e, ok := encoders[name]
+	return e, ok
+}
+
+// EncoderNames returns the names of all registered encoders, sorted
+func EncoderNames() []string {
+	var names []string
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pipeline is a chain of encoders applied left to right, written as "url|base64|url"
+type Pipeline []Encoder
+
+// ParsePipeline builds a pipeline from its "a|b|c" form
+func ParsePipeline(spec string) (Pipeline, error) {
+	var p Pipeline
+	for _, name := range strings.Split(spec, "|") {
+		name = strings.TrimSpace(name)
+		e, ok := LookupEncoder(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown encoder %q (available: %s)", name, strings.Join(EncoderNames(), ", "))
+		}
+		p = append(p, e)
+	}
+	return p, nil
+}
+
+// Encode runs the input through every encoder in the chain
+func (p Pipeline) Encode(input string) string {
+	for _, e := range p {
+		input = e.Encode(input)
+	}
+	return input
+}
+
+// String returns the chain in its "a|b|c" form
+func (p Pipeline) String() string {
+	names := make([]string, len(p))
+	for i, e := range p {
+		names[i] = e.Name()
+	}
+	return strings.Join(names, "|")
+}
+
+// Variant is an encoded form of a payload keyed by the chain that produced it
+type Variant struct {
+	Chain string `json:"chain"`
+	Value string `json:"value"`
+}
+
+// EncodeVariants encodes the input once per pipeline
+func EncodeVariants(input string, pipelines []Pipeline) []Variant {
+	variants := make([]Variant, 0, len(pipelines))
+	for _, p := range pipelines {
+		variants = append(variants, Variant{Chain: p.String(), Value: p.Encode(input)})
+	}
+	return variants
+}
+
 // EncodeURL returns URL-encoded representation of input string
 func EncodeURL(input string) string {
 	return url.QueryEscape(input)
@@ -17,20 +128,20 @@ func EncodeBase64(input string) string {
 	return base64.StdEncoding.EncodeToString([]byte(input))
 }
 
-// EncodeHex returns a hex-encoded representation of the input string
+// EncodeHex returns a \xNN escape for every byte of the input's UTF-8 encoding
 func EncodeHex(input string) string {
 	var result strings.Builder
-	for _, c := range input {
-		result.WriteString(fmt.Sprintf("\\x%02x", c))
+	for _, b := range []byte(input) {
+		result.WriteString(fmt.Sprintf("\\x%02x", b))
 	}
 	return result.String()
 }
 
-// EncodeUnicode returns a Unicode-escaped representation of the input string
+// EncodeUnicode returns a \uNNNN escape for every UTF-16 code unit, so astral runes become surrogate pairs
 func EncodeUnicode(input string) string {
 	var result strings.Builder
-	for _, r := range input {
-		result.WriteString(fmt.Sprintf("\\u%04x", r))
+	for _, u := range utf16.Encode([]rune(input)) {
+		result.WriteString(fmt.Sprintf("\\u%04x", u))
 	}
 	return result.String()
 }
@@ -52,3 +163,127 @@ func EncodeCMDi(input string) string {
 	}
 	return encoded
 }
+
+// EncodeHTMLDecimal returns HTML decimal character references (&#60;)
+func EncodeHTMLDecimal(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("&#%d;", r))
+	}
+	return result.String()
+}
+
+// EncodeHTMLDecimalPadded returns zero-padded HTML decimal references (&#0000060;)
+func EncodeHTMLDecimalPadded(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("&#%07d;", r))
+	}
+	return result.String()
+}
+
+// EncodeHTMLHex returns HTML hex character references (&#x3c;)
+func EncodeHTMLHex(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("&#x%x;", r))
+	}
+	return result.String()
+}
+
+// EncodeHTMLHexPadded returns zero-padded HTML hex references (&#x000003c;)
+func EncodeHTMLHexPadded(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("&#x%07x;", r))
+	}
+	return result.String()
+}
+
+// EncodeJSHex returns JavaScript string escapes, \xNN where possible and \uNNNN otherwise
+func EncodeJSHex(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		if r <= 0xff {
+			result.WriteString(fmt.Sprintf("\\x%02x", r))
+			continue
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			result.WriteString(fmt.Sprintf("\\u%04x", u))
+		}
+	}
+	return result.String()
+}
+
+// EncodeJSUnicode returns ES6 code point escapes (\u{3c})
+func EncodeJSUnicode(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("\\u{%x}", r))
+	}
+	return result.String()
+}
+
+// EncodeCSS returns six-digit CSS escapes (\00003c), which need no terminating space
+func EncodeCSS(input string) string {
+	var result strings.Builder
+	for _, r := range input {
+		result.WriteString(fmt.Sprintf("\\%06x", r))
+	}
+	return result.String()
+}
+
+// EncodeDoubleURL URL-encodes the input twice (< becomes %253C)
+func EncodeDoubleURL(input string) string {
+	return url.QueryEscape(url.QueryEscape(input))
+}
+
+// EncodeFullURL percent-encodes every byte, including unreserved characters
+func EncodeFullURL(input string) string {
+	var result strings.Builder
+	for _, b := range []byte(input) {
+		result.WriteString(fmt.Sprintf("%%%02X", b))
+	}
+	return result.String()
+}
+
+// EncodeOverlongUTF8 percent-encodes ASCII as two-byte overlong UTF-8 sequences (/ becomes %C0%AF)
+func EncodeOverlongUTF8(input string) string {
+	var result strings.Builder
+	for _, b := range []byte(input) {
+		if b < 0x80 {
+			result.WriteString(fmt.Sprintf("%%%02X%%%02X", 0xC0|b>>6, 0x80|b&0x3F))
+		} else {
+			result.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return result.String()
+}
+
+// EncodeUTF16LE returns the raw UTF-16 little-endian bytes; chain with base64 or url to print them
+func EncodeUTF16LE(input string) string {
+	return encodeUTF16(input, binary.LittleEndian)
+}
+
+// EncodeUTF16BE returns the raw UTF-16 big-endian bytes; chain with base64 or url to print them
+func EncodeUTF16BE(input string) string {
+	return encodeUTF16(input, binary.BigEndian)
+}
+
+func encodeUTF16(input string, order binary.ByteOrder) string {
+	units := utf16.Encode([]rune(input))
+	out := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(out[2*i:], u)
+	}
+	return string(out)
+}
+
+// EncodeOctal returns shell octal escapes (\074) usable with printf or $'...'
+func EncodeOctal(input string) string {
+	var result strings.Builder
+	for _, b := range []byte(input) {
+		result.WriteString(fmt.Sprintf("\\%03o", b))
+	}
+	return result.String()
+}
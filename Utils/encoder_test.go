@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+// TestEncodeDecodeRoundTrip checks that every registered encoder is reversed by the decoder of the
+// same name, including for multi-byte and astral input
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	inputs := []string{
+		`<script>alert(1)</script>`,
+		`'; cat /etc/passwd | nc $(id) &`,
+		"é€😀 <a href=\"x\">",
+	}
+	for _, name := range EncoderNames() {
+		e, _ := LookupEncoder(name)
+		d, ok := LookupDecoder(name)
+		if !ok {
+			t.Errorf("%s: no decoder registered", name)
+			continue
+		}
+		for _, input := range inputs {
+			encoded := e.Encode(input)
+			got, err := d.Decode(encoded)
+			if err != nil {
+				t.Errorf("%s: decoding %q: %v", name, encoded, err)
+				continue
+			}
+			if got != input {
+				t.Errorf("%s: %q encoded as %q decodes to %q", name, input, encoded, got)
+			}
+		}
+	}
+}
+
+// TestAnalyzeDetectsEscapes checks that auto-detection recognises the escape encoders' output
+func TestAnalyzeDetectsEscapes(t *testing.T) {
+	input := "é€😀 <a>"
+	for _, name := range []string{"hex", "unicode", "css", "octal"} {
+		e, _ := LookupEncoder(name)
+		steps := Analyze(e.Encode(input))
+		if len(steps) == 0 || steps[0].Decoder != name || steps[0].Output != input {
+			t.Errorf("%s: Analyze(%q) = %+v", name, e.Encode(input), steps)
+		}
+	}
+}
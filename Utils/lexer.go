@@ -0,0 +1,612 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind classifies the tokens produced by the SQL, JavaScript and shell lexers
+type tokenKind int
+
+const (
+	tokSpace tokenKind = iota
+	tokComment
+	tokLineComment
+	tokString
+	tokWord
+	tokNumber
+	tokPunct
+	// tokBreakout is a leading quote that closes the quote the payload is injected into
+	tokBreakout
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// breakoutQuote returns the index of a leading breakout quote, or -1. A payload that starts
+// with a quote, or contains an odd number of them, is closing a quote opened by the target.
+func breakoutQuote(input string, quotes string) int {
+	i := strings.IndexAny(input, quotes)
+	if i < 0 {
+		return -1
+	}
+	if i == 0 || strings.Count(input, input[i:i+1])%2 == 1 {
+		return i
+	}
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || isAlnum(c) || c >= 0x80
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// quotedEnd returns the index just past the quote starting at i; unterminated quotes run to the end
+func quotedEnd(input string, i int, escapes bool) int {
+	q := input[i]
+	for j := i + 1; j < len(input); j++ {
+		switch {
+		case escapes && input[j] == '\\':
+			j++
+		case input[j] == q && q == '\'' && !escapes && j+1 < len(input) && input[j+1] == q:
+			// SQL doubles a quote to escape it
+			j++
+		case input[j] == q:
+			return j + 1
+		}
+	}
+	return len(input)
+}
+
+// longestPunct matches the longest of the given multi-character operators at i
+func longestPunct(input string, i int, ops []string) int {
+	for _, op := range ops {
+		if strings.HasPrefix(input[i:], op) {
+			return len(op)
+		}
+	}
+	return 1
+}
+
+var sqlOperators = []string{"<=>", "<>", "<=", ">=", "!=", "||", "&&", "::", ":="}
+
+// lexSQL splits a SQL payload into tokens. Unterminated strings and block comments run to the end.
+func lexSQL(input string) []token {
+	var toks []token
+	breakout := breakoutQuote(input, `'"`)
+	for i := 0; i < len(input); {
+		c := input[i]
+		start := i
+		switch {
+		case i == breakout:
+			toks = append(toks, token{tokBreakout, input[i : i+1]})
+			i++
+			continue
+		case isSpace(c):
+			for i < len(input) && isSpace(input[i]) {
+				i++
+			}
+			toks = append(toks, token{tokSpace, input[start:i]})
+			continue
+		case strings.HasPrefix(input[i:], "--") || c == '#':
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			toks = append(toks, token{tokLineComment, input[start:i]})
+			continue
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 4
+			}
+			toks = append(toks, token{tokComment, input[start:i]})
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(input, i, c != '\'')
+			toks = append(toks, token{tokString, input[start:i]})
+			continue
+		case isDigit(c):
+			for i < len(input) && (isAlnum(input[i]) || input[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{tokNumber, input[start:i]})
+			continue
+		case isWordChar(c):
+			for i < len(input) && isWordChar(input[i]) {
+				i++
+			}
+			toks = append(toks, token{tokWord, input[start:i]})
+			continue
+		}
+		i += longestPunct(input, i, sqlOperators)
+		toks = append(toks, token{tokPunct, input[start:i]})
+	}
+	return toks
+}
+
+var jsOperators = []string{
+	">>>=", "===", "!==", "**=", "<<=", ">>=", ">>>", "...", "=>", "==", "!=", "<=", ">=",
+	"&&", "||", "??", "?.", "++", "--", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "**", "<<", ">>",
+}
+
+// lexJS splits a JavaScript snippet into tokens; regular expression literals are not recognised
+func lexJS(input string) []token {
+	var toks []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		start := i
+		switch {
+		case isSpace(c):
+			for i < len(input) && isSpace(input[i]) {
+				i++
+			}
+			toks = append(toks, token{tokSpace, input[start:i]})
+			continue
+		case strings.HasPrefix(input[i:], "//"):
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			toks = append(toks, token{tokLineComment, input[start:i]})
+			continue
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 4
+			}
+			toks = append(toks, token{tokComment, input[start:i]})
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(input, i, true)
+			toks = append(toks, token{tokString, input[start:i]})
+			continue
+		case isDigit(c):
+			for i < len(input) && (isAlnum(input[i]) || input[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{tokNumber, input[start:i]})
+			continue
+		case isWordChar(c) && c != '@':
+			for i < len(input) && isWordChar(input[i]) && input[i] != '@' {
+				i++
+			}
+			toks = append(toks, token{tokWord, input[start:i]})
+			continue
+		}
+		i += longestPunct(input, i, jsOperators)
+		toks = append(toks, token{tokPunct, input[start:i]})
+	}
+	return toks
+}
+
+// ShellDialect selects the shell grammar used by the shell obfuscator and validator
+type ShellDialect string
+
+const (
+	ShellPOSIX      ShellDialect = "posix"
+	ShellCmd        ShellDialect = "cmd"
+	ShellPowerShell ShellDialect = "powershell"
+)
+
+var (
+	posixOperators      = []string{"$(", "&&", "||", ">>", "2>", ";", "|", "&", "(", ")", "`", "<", ">", "\n"}
+	cmdOperators        = []string{"&&", "||", ">>", "2>", "&", "|", "(", ")", "<", ">", "\n"}
+	powershellOperators = []string{"$(", "@(", "&&", "||", ">>", "2>", ";", "|", "&", "(", ")", "{", "}", "<", ">", "\n"}
+)
+
+// shellEscape returns the character that escapes the next one outside quotes
+func shellEscape(dialect ShellDialect) byte {
+	switch dialect {
+	case ShellCmd:
+		return '^'
+	case ShellPowerShell:
+		return '`'
+	}
+	return '\\'
+}
+
+// lexShell splits a command line into spaces, operators and words; quotes stay inside words
+func lexShell(dialect ShellDialect, input string) []token {
+	quotes, operators := `'"`, posixOperators
+	switch dialect {
+	case ShellCmd:
+		quotes, operators = `"`, cmdOperators
+	case ShellPowerShell:
+		operators = powershellOperators
+	}
+
+	var toks []token
+	breakout := breakoutQuote(input, quotes)
+	for i := 0; i < len(input); {
+		c := input[i]
+		start := i
+		switch {
+		case i == breakout:
+			toks = append(toks, token{tokBreakout, input[i : i+1]})
+			i++
+			continue
+		case dialect == ShellPOSIX && (strings.HasPrefix(input[i:], "${IFS}") || strings.HasPrefix(input[i:], "$IFS")):
+			// Unquoted $IFS splits words exactly like a space does
+			i += strings.Index(input[i:], "S") + 1
+			if i < len(input) && input[i] == '}' {
+				i++
+			}
+			toks = append(toks, token{tokSpace, input[start:i]})
+			continue
+		case c == ' ' || c == '\t':
+			for i < len(input) && (input[i] == ' ' || input[i] == '\t') {
+				i++
+			}
+			toks = append(toks, token{tokSpace, input[start:i]})
+			continue
+		case dialect != ShellCmd && c == '#' && (i == 0 || isSpace(input[i-1]) || strings.ContainsRune(";&|(", rune(input[i-1]))):
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			toks = append(toks, token{tokLineComment, input[start:i]})
+			continue
+		}
+
+		if n := matchOperator(input[i:], operators); n > 0 {
+			i += n
+			toks = append(toks, token{tokPunct, input[start:i]})
+			continue
+		}
+
+		for i < len(input) {
+			c := input[i]
+			if c == ' ' || c == '\t' || matchOperator(input[i:], operators) > 0 {
+				break
+			}
+			if dialect == ShellPOSIX && (strings.HasPrefix(input[i:], "${IFS}") || strings.HasPrefix(input[i:], "$IFS")) {
+				break
+			}
+			switch {
+			case strings.ContainsRune(quotes, rune(c)) && i != breakout:
+				i = quotedEnd(input, i, c == '"' && dialect == ShellPOSIX)
+			case c == shellEscape(dialect) && i+1 < len(input):
+				i += 2
+			default:
+				i++
+			}
+		}
+		toks = append(toks, token{tokWord, input[start:i]})
+	}
+	return toks
+}
+
+func matchOperator(s string, operators []string) int {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return len(op)
+		}
+	}
+	return 0
+}
+
+// shellWordValue removes the quoting and escapes the shell strips before running a word
+func shellWordValue(dialect ShellDialect, word string) string {
+	var out strings.Builder
+	inQuote := false
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case dialect == ShellCmd && c == '"':
+			inQuote = !inQuote
+			out.WriteByte(c)
+		case dialect == ShellCmd && c == '^' && !inQuote && i+1 < len(word):
+			i++
+			out.WriteByte(word[i])
+		case dialect == ShellCmd:
+			out.WriteByte(c)
+		case dialect == ShellPowerShell && c == '`' && i+1 < len(word):
+			i++
+			out.WriteByte(word[i])
+		case dialect == ShellPowerShell && c == '"':
+			j := i + 1
+			for ; j < len(word) && word[j] != '"'; j++ {
+				if word[j] == '`' && j+1 < len(word) {
+					j++
+				}
+				out.WriteByte(word[j])
+			}
+			i = j
+		case dialect == ShellPOSIX && c == '\\' && i+1 < len(word):
+			i++
+			out.WriteByte(word[i])
+		case c == '\'':
+			end := strings.IndexByte(word[i+1:], '\'')
+			if end < 0 {
+				end = len(word) - i - 1
+			}
+			out.WriteString(word[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			j := i + 1
+			for ; j < len(word) && word[j] != '"'; j++ {
+				if word[j] == '\\' && j+1 < len(word) && strings.ContainsRune("$`\"\\\n", rune(word[j+1])) {
+					j++
+				}
+				out.WriteByte(word[j])
+			}
+			i = j
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// significant drops whitespace and block comments, which never change meaning between tokens
+func significant(toks []token) []token {
+	var out []token
+	for _, t := range toks {
+		if t.kind != tokSpace && t.kind != tokComment {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// compareTokens checks two token streams token by token using the given equality
+func compareTokens(lang string, original, obfuscated []token, equal func(a, b token) bool) error {
+	a, b := significant(original), significant(obfuscated)
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].kind != b[i].kind || !equal(a[i], b[i]) {
+			return fmt.Errorf("%s token %d changed from %q to %q", lang, i+1, a[i].text, b[i].text)
+		}
+	}
+	if len(a) != len(b) {
+		return fmt.Errorf("%s token count changed from %d to %d", lang, len(a), len(b))
+	}
+	return nil
+}
+
+// unterminatedComment reports a block comment that swallows the rest of the payload
+func unterminatedComment(toks []token) bool {
+	for _, t := range toks {
+		if t.kind == tokComment && (len(t.text) < 4 || !strings.HasSuffix(t.text, "*/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlKind classifies the nodes produced by lexHTML
+type htmlKind int
+
+const (
+	htmlText htmlKind = iota
+	htmlTag
+	// htmlRaw is a comment, CDATA section or doctype, copied verbatim
+	htmlRaw
+	// htmlScript is the body of a <script> element
+	htmlScript
+)
+
+// htmlAttr keeps every byte of an attribute so a tag can be rebuilt exactly
+type htmlAttr struct {
+	sep   string // whitespace and slashes before the attribute
+	name  string
+	eq    string // "=" with any surrounding whitespace, empty for valueless attributes
+	quote byte   // 0 for unquoted values
+	value string // value without its quotes
+}
+
+type htmlNode struct {
+	kind    htmlKind
+	text    string // text, raw and script nodes
+	closing bool
+	name    string
+	attrs   []htmlAttr
+	tail    string // whitespace and "/" before ">"
+	open    bool   // tag runs to the end of the input without ">"
+}
+
+// lexHTML splits markup into text, tags and raw sections following the HTML tokenizer's rules
+// for tag names and attributes, so filter-evasion payloads are read the way a browser reads them.
+func lexHTML(input string) []htmlNode {
+	var nodes []htmlNode
+	text := 0
+	flush := func(end int) {
+		if end > text {
+			nodes = append(nodes, htmlNode{kind: htmlText, text: input[text:end]})
+		}
+	}
+
+	for i := 0; i < len(input); {
+		if input[i] != '<' {
+			i++
+			continue
+		}
+
+		start, rest := i, input[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			flush(i)
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 7
+			}
+			nodes = append(nodes, htmlNode{kind: htmlRaw, text: input[start:i]})
+			text = i
+			continue
+		case strings.HasPrefix(rest, "<![CDATA["):
+			flush(i)
+			end := strings.Index(rest, "]]>")
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 3
+			}
+			nodes = append(nodes, htmlNode{kind: htmlRaw, text: input[start:i]})
+			text = i
+			continue
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			flush(i)
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 1
+			}
+			nodes = append(nodes, htmlNode{kind: htmlRaw, text: input[start:i]})
+			text = i
+			continue
+		}
+
+		j := i + 1
+		closing := j < len(input) && input[j] == '/'
+		if closing {
+			j++
+		}
+		if j >= len(input) || !(input[j] >= 'a' && input[j] <= 'z' || input[j] >= 'A' && input[j] <= 'Z') {
+			i++
+			continue
+		}
+
+		flush(i)
+		node, end := lexHTMLTag(input, j)
+		node.closing = closing
+		nodes = append(nodes, node)
+		i, text = end, end
+
+		// Script bodies are JavaScript up to the next </script
+		if !closing && !node.open && strings.EqualFold(node.name, "script") {
+			close := strings.Index(strings.ToLower(input[i:]), "</script")
+			if close < 0 {
+				close = len(input) - i
+			}
+			if close > 0 {
+				nodes = append(nodes, htmlNode{kind: htmlScript, text: input[i : i+close]})
+			}
+			i += close
+			text = i
+		}
+	}
+	flush(len(input))
+	return nodes
+}
+
+// lexHTMLTag reads a tag whose name starts at j and returns it with the index just past it
+func lexHTMLTag(input string, j int) (htmlNode, int) {
+	node := htmlNode{kind: htmlTag}
+	start := j
+	for j < len(input) && !isSpace(input[j]) && input[j] != '/' && input[j] != '>' {
+		j++
+	}
+	node.name = input[start:j]
+
+	for {
+		sepStart := j
+		for j < len(input) && (isSpace(input[j]) || input[j] == '/') {
+			j++
+		}
+		if j >= len(input) {
+			node.tail, node.open = input[sepStart:], true
+			return node, len(input)
+		}
+		if input[j] == '>' {
+			node.tail = input[sepStart:j]
+			return node, j + 1
+		}
+
+		attr := htmlAttr{sep: input[sepStart:j]}
+		nameStart := j
+		j++ // an attribute name may start with "="
+		for j < len(input) && !isSpace(input[j]) && input[j] != '/' && input[j] != '>' && input[j] != '=' {
+			j++
+		}
+		attr.name = input[nameStart:j]
+
+		k := j
+		for k < len(input) && isSpace(input[k]) {
+			k++
+		}
+		if k < len(input) && input[k] == '=' {
+			k++
+			for k < len(input) && isSpace(input[k]) {
+				k++
+			}
+			attr.eq = input[j:k]
+			j = k
+			if j < len(input) && (input[j] == '"' || input[j] == '\'') {
+				attr.quote = input[j]
+				end := strings.IndexByte(input[j+1:], attr.quote)
+				if end < 0 {
+					attr.value, j = input[j+1:], len(input)
+				} else {
+					attr.value, j = input[j+1:j+1+end], j+end+2
+				}
+			} else {
+				valueStart := j
+				for j < len(input) && !isSpace(input[j]) && input[j] != '>' {
+					j++
+				}
+				attr.value = input[valueStart:j]
+			}
+		}
+		node.attrs = append(node.attrs, attr)
+	}
+}
+
+// render rebuilds the markup of a node
+func (n htmlNode) render() string {
+	if n.kind != htmlTag {
+		return n.text
+	}
+	var b strings.Builder
+	b.WriteByte('<')
+	if n.closing {
+		b.WriteByte('/')
+	}
+	b.WriteString(n.name)
+	for _, a := range n.attrs {
+		b.WriteString(a.sep)
+		b.WriteString(a.name)
+		b.WriteString(a.eq)
+		if a.quote != 0 {
+			b.WriteByte(a.quote)
+		}
+		b.WriteString(a.value)
+		if a.quote != 0 {
+			b.WriteByte(a.quote)
+		}
+	}
+	b.WriteString(n.tail)
+	if !n.open {
+		b.WriteByte('>')
+	}
+	return b.String()
+}
+
+// jsAttribute reports whether an attribute value is JavaScript, returning the scheme prefix
+// ("javascript:") that precedes the code in URL attributes
+func jsAttribute(a htmlAttr) (bool, string) {
+	if len(a.name) > 2 && strings.EqualFold(a.name[:2], "on") {
+		return true, ""
+	}
+	trimmed := strings.TrimLeft(a.value, " \t\n")
+	if len(trimmed) >= 11 && strings.EqualFold(trimmed[:11], "javascript:") {
+		return true, a.value[:len(a.value)-len(trimmed)+11]
+	}
+	return false, ""
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The expected streams below are written out by hand so that a lexer bug cannot
+// agree with itself the way it does inside the validators.
+
+func tok(kind tokenKind, text string) token {
+	return token{kind: kind, text: text}
+}
+
+func TestLexSQL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{"' OR 1=1-- -", []token{
+			tok(tokBreakout, "'"), tok(tokSpace, " "), tok(tokWord, "OR"), tok(tokSpace, " "),
+			tok(tokNumber, "1"), tok(tokPunct, "="), tok(tokNumber, "1"), tok(tokLineComment, "-- -"),
+		}},
+		{`1 UNION SELECT 'a--b',"c#d"/*x*/#e`, []token{
+			tok(tokNumber, "1"), tok(tokSpace, " "), tok(tokWord, "UNION"), tok(tokSpace, " "),
+			tok(tokWord, "SELECT"), tok(tokSpace, " "), tok(tokString, "'a--b'"), tok(tokPunct, ","),
+			tok(tokString, `"c#d"`), tok(tokComment, "/*x*/"), tok(tokLineComment, "#e"),
+		}},
+		{"x='it''s'", []token{
+			tok(tokWord, "x"), tok(tokPunct, "="), tok(tokString, "'it''s'"),
+		}},
+		{"a<=>b||c", []token{
+			tok(tokWord, "a"), tok(tokPunct, "<=>"), tok(tokWord, "b"), tok(tokPunct, "||"), tok(tokWord, "c"),
+		}},
+		{"SLEEP(5)\n-- x\nAND 1", []token{
+			tok(tokWord, "SLEEP"), tok(tokPunct, "("), tok(tokNumber, "5"), tok(tokPunct, ")"), tok(tokSpace, "\n"),
+			tok(tokLineComment, "-- x"), tok(tokSpace, "\n"), tok(tokWord, "AND"), tok(tokSpace, " "), tok(tokNumber, "1"),
+		}},
+		{"1/* open", []token{
+			tok(tokNumber, "1"), tok(tokComment, "/* open"),
+		}},
+	}
+	for _, tt := range tests {
+		if got := lexSQL(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lexSQL(%q)\n got %v\nwant %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexJS(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{"alert(1)//x", []token{
+			tok(tokWord, "alert"), tok(tokPunct, "("), tok(tokNumber, "1"), tok(tokPunct, ")"), tok(tokLineComment, "//x"),
+		}},
+		{"a===b?.c", []token{
+			tok(tokWord, "a"), tok(tokPunct, "==="), tok(tokWord, "b"), tok(tokPunct, "?."), tok(tokWord, "c"),
+		}},
+		{`'a\'b'+"c"`, []token{
+			tok(tokString, `'a\'b'`), tok(tokPunct, "+"), tok(tokString, `"c"`),
+		}},
+		{"`t${x}`;x/*y*/y", []token{
+			tok(tokString, "`t${x}`"), tok(tokPunct, ";"), tok(tokWord, "x"), tok(tokComment, "/*y*/"), tok(tokWord, "y"),
+		}},
+		{"'it\"s'", []token{
+			tok(tokString, "'it\"s'"),
+		}},
+	}
+	for _, tt := range tests {
+		if got := lexJS(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lexJS(%q)\n got %v\nwant %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexShell(t *testing.T) {
+	tests := []struct {
+		dialect ShellDialect
+		input   string
+		want    []token
+	}{
+		{ShellPOSIX, ";cat /etc/passwd #x", []token{
+			tok(tokPunct, ";"), tok(tokWord, "cat"), tok(tokSpace, " "), tok(tokWord, "/etc/passwd"),
+			tok(tokSpace, " "), tok(tokLineComment, "#x"),
+		}},
+		{ShellPOSIX, "a$(id)b", []token{
+			tok(tokWord, "a"), tok(tokPunct, "$("), tok(tokWord, "id"), tok(tokPunct, ")"), tok(tokWord, "b"),
+		}},
+		{ShellPOSIX, `echo${IFS}'a b'"c;d"`, []token{
+			tok(tokWord, "echo"), tok(tokSpace, "${IFS}"), tok(tokWord, `'a b'"c;d"`),
+		}},
+		{ShellPOSIX, "x#y&&b||c|d", []token{
+			tok(tokWord, "x#y"), tok(tokPunct, "&&"), tok(tokWord, "b"), tok(tokPunct, "||"),
+			tok(tokWord, "c"), tok(tokPunct, "|"), tok(tokWord, "d"),
+		}},
+		{ShellPOSIX, "'; id #", []token{
+			tok(tokBreakout, "'"), tok(tokPunct, ";"), tok(tokSpace, " "), tok(tokWord, "id"),
+			tok(tokSpace, " "), tok(tokLineComment, "#"),
+		}},
+		{ShellPOSIX, `a\;b`, []token{
+			tok(tokWord, `a\;b`),
+		}},
+		{ShellCmd, "& who^&ami # x", []token{
+			tok(tokPunct, "&"), tok(tokSpace, " "), tok(tokWord, "who^&ami"), tok(tokSpace, " "),
+			tok(tokWord, "#"), tok(tokSpace, " "), tok(tokWord, "x"),
+		}},
+		{ShellCmd, `x "a&b"&c`, []token{
+			tok(tokWord, "x"), tok(tokSpace, " "), tok(tokWord, `"a&b"`), tok(tokPunct, "&"), tok(tokWord, "c"),
+		}},
+		{ShellPowerShell, "; who`;ami | Select -First 1", []token{
+			tok(tokPunct, ";"), tok(tokSpace, " "), tok(tokWord, "who`;ami"), tok(tokSpace, " "),
+			tok(tokPunct, "|"), tok(tokSpace, " "), tok(tokWord, "Select"), tok(tokSpace, " "),
+			tok(tokWord, "-First"), tok(tokSpace, " "), tok(tokWord, "1"),
+		}},
+		{ShellPowerShell, "$(whoami){'a;b'}", []token{
+			tok(tokPunct, "$("), tok(tokWord, "whoami"), tok(tokPunct, ")"), tok(tokPunct, "{"),
+			tok(tokWord, "'a;b'"), tok(tokPunct, "}"),
+		}},
+	}
+	for _, tt := range tests {
+		if got := lexShell(tt.dialect, tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lexShell(%s, %q)\n got %v\nwant %v", tt.dialect, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestShellWordValue(t *testing.T) {
+	tests := []struct {
+		dialect ShellDialect
+		word    string
+		want    string
+	}{
+		{ShellPOSIX, "c''at", "cat"},
+		{ShellPOSIX, `c\at`, "cat"},
+		{ShellPOSIX, `"a\"b"`, `a"b`},
+		{ShellPOSIX, `'a\b'`, `a\b`},
+		{ShellPOSIX, `"a\b"`, `a\b`},
+		{ShellPOSIX, `/e"t"c/pa''ss\wd`, "/etc/passwd"},
+		{ShellCmd, "who^ami", "whoami"},
+		{ShellCmd, `"a^b"`, `"a^b"`},
+		{ShellPowerShell, "w`ho", "who"},
+		{ShellPowerShell, "\"a`\"b\"", `a"b`},
+		{ShellPowerShell, "'a`b'", "a`b"},
+	}
+	for _, tt := range tests {
+		if got := shellWordValue(tt.dialect, tt.word); got != tt.want {
+			t.Errorf("shellWordValue(%s, %q) = %q, want %q", tt.dialect, tt.word, got, tt.want)
+		}
+	}
+}
+
+// describeHTML summarises nodes as kind:name[attr=value]... for readable expectations
+func describeHTML(nodes []htmlNode) []string {
+	var out []string
+	for _, n := range nodes {
+		switch n.kind {
+		case htmlTag:
+			var b strings.Builder
+			b.WriteString("tag:")
+			if n.closing {
+				b.WriteByte('/')
+			}
+			b.WriteString(n.name)
+			for _, a := range n.attrs {
+				fmt.Fprintf(&b, "[%s%s%s]", a.name, strings.TrimSpace(a.eq), a.value)
+			}
+			out = append(out, b.String())
+		case htmlScript:
+			out = append(out, "script:"+n.text)
+		case htmlRaw:
+			out = append(out, "raw:"+n.text)
+		default:
+			out = append(out, "text:"+n.text)
+		}
+	}
+	return out
+}
+
+func TestLexHTML(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"<img src=x onerror=alert(1)>", []string{"tag:img[src=x][onerror=alert(1)]"}},
+		{"<svg/onload=alert(1)>", []string{"tag:svg[onload=alert(1)]"}},
+		{`<a href="x>y" title='q"r'>t</a>`, []string{`tag:a[href=x>y][title=q"r]`, "text:t", "tag:/a"}},
+		{"a<script>if(1<2)x='</b>'</script>", []string{"text:a", "tag:script", "script:if(1<2)x='</b>'", "tag:/script"}},
+		{"<!--<img src=x>--><![CDATA[<b>]]>", []string{"raw:<!--<img src=x>-->", "raw:<![CDATA[<b>]]>"}},
+		{"1 < 2 <3", []string{"text:1 < 2 <3"}},
+		{"<input autofocus onfocus=alert(1)", []string{"tag:input[autofocus][onfocus=alert(1)]"}},
+	}
+	for _, tt := range tests {
+		if got := describeHTML(lexHTML(tt.input)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lexHTML(%q)\n got %q\nwant %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestLexersLossless checks that every lexer keeps every byte, so rebuilding the input from its
+// tokens gives it back exactly; obfuscators rely on this to leave untouched tokens intact
+func TestLexersLossless(t *testing.T) {
+	inputs := []string{
+		"' OR 'a'='a' -- x",
+		`1 UNION SELECT "a\"b",'it''s'/*c*/#d`,
+		"';alert(`x${1}`)//",
+		"<svg/onload=alert(1)><script>a</script><!--c",
+		`'; echo "a'b" $(id) ${IFS}x #c`,
+		"a^&b & \"c\"",
+		"unterminated 'quote",
+	}
+	concat := func(toks []token) string {
+		var b strings.Builder
+		for _, t := range toks {
+			b.WriteString(t.text)
+		}
+		return b.String()
+	}
+	for _, in := range inputs {
+		if got := concat(lexSQL(in)); got != in {
+			t.Errorf("lexSQL lost bytes: %q -> %q", in, got)
+		}
+		if got := concat(lexJS(in)); got != in {
+			t.Errorf("lexJS lost bytes: %q -> %q", in, got)
+		}
+		for _, d := range []ShellDialect{ShellPOSIX, ShellCmd, ShellPowerShell} {
+			if got := concat(lexShell(d, in)); got != in {
+				t.Errorf("lexShell(%s) lost bytes: %q -> %q", d, in, got)
+			}
+		}
+		var b strings.Builder
+		for _, n := range lexHTML(in) {
+			b.WriteString(n.render())
+		}
+		if got := b.String(); got != in {
+			t.Errorf("lexHTML lost bytes: %q -> %q", in, got)
+		}
+	}
+}
@@ -1,38 +1,51 @@
 package utils
 
 import (
+	"fmt"
 	"math/rand"
 	"strings"
-	"time"
 )
 
-// Obfuscate inserts random spacing or comments between characters (for general purpose)
-func Obfuscate(input string) string {
-	rand.Seed(time.Now().UnixNano())
-	var obf strings.Builder
-	for _, c := range input {
-		obf.WriteRune(c)
-		switch rand.Intn(3) {
-		case 0:
-			obf.WriteString(" ")
-		case 1:
-			obf.WriteString("/**/")
-		case 2:
-			obf.WriteString("<!-- -->")
-		}
+// NewRand returns the per-run random source; identical seeds give identical obfuscation
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// sqlKeywords are the case-insensitive words RandomizeSQLCase may re-case; identifiers such as
+// table names can be case-sensitive and are left alone
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		SELECT UNION ALL DISTINCT FROM WHERE AND OR NOT NULL IS LIKE IN BETWEEN EXISTS AS ON
+		ORDER BY GROUP HAVING LIMIT OFFSET INSERT INTO VALUES UPDATE SET DELETE DROP TABLE
+		CASE WHEN THEN ELSE END IF WAITFOR DELAY EXEC EXECUTE DECLARE CAST CONVERT
+		SLEEP PG_SLEEP BENCHMARK VERSION DATABASE USER CURRENT_USER CONCAT SUBSTRING SUBSTR
+		ASCII CHAR CHR LENGTH LEN COUNT UPPER LOWER HEX RANDOMBLOB`) {
+		sqlKeywords[kw] = true
 	}
-	return obf.String()
 }
 
 // RandomizeSQLCase randomizes the casing of SQL keywords to evade WAFs
-func RandomizeSQLCase(input string) string {
-	rand.Seed(time.Now().UnixNano())
+func RandomizeSQLCase(r *rand.Rand, input string) string {
 	var mixed strings.Builder
-	for _, r := range input {
-		if rand.Intn(2) == 0 {
-			mixed.WriteRune(toUpper(r))
+	for _, t := range lexSQL(input) {
+		if t.kind == tokWord && sqlKeywords[strings.ToUpper(t.text)] {
+			mixed.WriteString(randomCase(r, t.text))
+		} else {
+			mixed.WriteString(t.text)
+		}
+	}
+	return mixed.String()
+}
+
+func randomCase(r *rand.Rand, input string) string {
+	var mixed strings.Builder
+	for _, c := range input {
+		if r.Intn(2) == 0 {
+			mixed.WriteRune(toUpper(c))
 		} else {
-			mixed.WriteRune(toLower(r))
+			mixed.WriteRune(toLower(c))
 		}
 	}
 	return mixed.String()
@@ -52,64 +65,362 @@ func toLower(r rune) rune {
 	return r
 }
 
-// InsertSQLComments inserts comment tokens between SQL keywords
+// InsertSQLComments replaces the whitespace between SQL tokens with inline comments
 func InsertSQLComments(input string) string {
-	replacements := map[string]string{
-		"SELECT": "SE/**/LECT",
-		"FROM":   "FR/**/OM",
-		"WHERE":  "WH/**/ERE",
-		"AND":    "A/**/ND",
-		"OR":     "O/**/R",
-		"UNION":  "UN/**/ION",
-		"INSERT": "IN/**/SERT",
-		"UPDATE": "UP/**/DATE",
-		"DELETE": "DE/**/LETE",
-		"DROP":   "DR/**/OP",
-		"TABLE":  "TA/**/BLE",
-	}
-
-	out := input
-	for key, val := range replacements {
-		out = strings.ReplaceAll(out, key, val)
-		out = strings.ReplaceAll(out, strings.ToLower(key), val)
-		out = strings.ReplaceAll(out, strings.ToUpper(key), val)
-	}
-
-	return out
-}
-
-// ObfuscateXSS applies XSS-specific obfuscation (spaces, comments)
-func ObfuscateXSS(input string) string {
-	rand.Seed(time.Now().UnixNano())
-	var obf strings.Builder
-	for _, c := range input {
-		obf.WriteRune(c)
-		// Obfuscate only inside script strings like "alert" or "onerror"
-		if rand.Intn(3) == 0 {
-			obf.WriteString(" ")
-		} else if rand.Intn(3) == 1 {
-			obf.WriteString("<!-- -->")
+	var out strings.Builder
+	for _, t := range lexSQL(input) {
+		if t.kind == tokSpace {
+			out.WriteString("/**/")
+		} else {
+			out.WriteString(t.text)
 		}
 	}
-	return obf.String()
+	return out.String()
 }
 
-// ObfuscateCMDi adds shell-specific obfuscation using random whitespace and chaining symbols
-func ObfuscateCMDi(input string) string {
-	rand.Seed(time.Now().UnixNano())
-	var result strings.Builder
-	for _, c := range input {
-		result.WriteRune(c)
-		switch rand.Intn(4) {
-		case 0:
-			result.WriteString(" ") // space
-		case 1:
-			result.WriteString(" # ") // shell comment
-		case 2:
-			result.WriteString(" \\") // escape
-		case 3:
-			result.WriteString(" && ") // chaining
-		}
-	}
-	return result.String()
+var sqlSpacers = []string{" ", "  ", "\t", "\n", "/**/", " /**/ "}
+
+// ObfuscateSQL varies whitespace, adds inline comments and re-cases keywords, touching only
+// the gaps between SQL tokens so string literals and comments are never split
+func ObfuscateSQL(r *rand.Rand, input string) string {
+	toks := lexSQL(input)
+	var out strings.Builder
+	for i, t := range toks {
+		switch {
+		case t.kind == tokSpace && i > 0 && toks[i-1].kind == tokLineComment:
+			// only a line break ends a line comment
+			out.WriteString("\n")
+		case t.kind == tokSpace:
+			out.WriteString(sqlSpacers[r.Intn(len(sqlSpacers))])
+		case t.kind == tokWord && sqlKeywords[strings.ToUpper(t.text)]:
+			out.WriteString(randomCase(r, t.text))
+		default:
+			out.WriteString(t.text)
+		}
+		if i+1 < len(toks) && sqlGapAllowed(t, toks[i+1]) && r.Intn(3) == 0 {
+			out.WriteString("/**/")
+		}
+	}
+	return out.String()
+}
+
+// sqlGapAllowed reports whether a comment may be inserted between two adjacent tokens
+func sqlGapAllowed(prev, next token) bool {
+	switch {
+	case prev.kind == tokSpace || next.kind == tokSpace || prev.kind == tokLineComment:
+		return false
+	case prev.kind == tokWord && next.text == "(":
+		// MySQL only resolves built-in functions written directly before "("
+		return false
+	case prev.kind == tokWord && next.kind == tokString:
+		// N'..', X'..' and E'..' prefixes must touch their literal
+		return false
+	case prev.text == "." || next.text == ".":
+		return false
+	}
+	return true
+}
+
+// ValidateSQL confirms an obfuscated SQL payload still lexes to the same tokens as the original
+func ValidateSQL(original, obfuscated string) error {
+	toks := lexSQL(obfuscated)
+	if unterminatedComment(toks) {
+		return fmt.Errorf("SQL block comment is not closed")
+	}
+	return compareTokens("SQL", lexSQL(original), toks, func(a, b token) bool {
+		if a.kind == tokWord && sqlKeywords[strings.ToUpper(a.text)] {
+			return strings.EqualFold(a.text, b.text)
+		}
+		return a.text == b.text
+	})
+}
+
+var (
+	// form feed separates attributes like a newline does, but keeps each payload on one txt line
+	htmlSpacers = []string{" ", "\t", "\f", "  "}
+	jsSpacers   = []string{" ", "\t", "/**/"}
+)
+
+// ObfuscateXSS applies XSS-specific obfuscation: tag and attribute names are re-cased, the
+// separators between attributes vary (including "/"), and JavaScript in event handlers,
+// javascript: URLs and <script> bodies gets comments between its tokens
+func ObfuscateXSS(r *rand.Rand, input string) string {
+	var out strings.Builder
+	for _, n := range lexHTML(input) {
+		switch n.kind {
+		case htmlScript:
+			out.WriteString(obfuscateJS(r, n.text, true))
+			continue
+		case htmlTag:
+		default:
+			out.WriteString(n.text)
+			continue
+		}
+
+		if isASCIIName(n.name) {
+			n.name = randomCase(r, n.name)
+		}
+		slashOK := true // "/" separates attributes unless it would extend an unquoted value
+		for i, a := range n.attrs {
+			if strings.Trim(a.sep, " \t\n\f\r") == "" {
+				choices := htmlSpacers
+				if slashOK {
+					choices = append(choices[:len(choices):len(choices)], "/")
+				}
+				a.sep = choices[r.Intn(len(choices))]
+			}
+			if js, scheme := jsAttribute(a); js {
+				code := a.value[len(scheme):]
+				a.value = randomCase(r, scheme) + obfuscateJS(r, code, a.quote != 0)
+			}
+			if isASCIIName(a.name) {
+				a.name = randomCase(r, a.name)
+			}
+			slashOK = a.eq == "" || a.quote != 0
+			n.attrs[i] = a
+		}
+		out.WriteString(n.render())
+	}
+	return out.String()
+}
+
+func isASCIIName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isAlnum(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// obfuscateJS puts comments (and whitespace when allowed) between JavaScript tokens
+func obfuscateJS(r *rand.Rand, input string, allowSpace bool) string {
+	toks := lexJS(input)
+	var out strings.Builder
+	for i, t := range toks {
+		out.WriteString(t.text)
+		if i+1 >= len(toks) || !jsGapAllowed(t, toks[i+1]) || r.Intn(3) != 0 {
+			continue
+		}
+		if allowSpace {
+			out.WriteString(jsSpacers[r.Intn(len(jsSpacers))])
+		} else {
+			out.WriteString("/**/")
+		}
+	}
+	return out.String()
+}
+
+// jsGapAllowed reports whether a comment may be inserted between two adjacent tokens
+func jsGapAllowed(prev, next token) bool {
+	switch {
+	case prev.kind == tokSpace || next.kind == tokSpace || prev.kind == tokLineComment:
+		return false
+	case strings.Contains(prev.text, "/") || strings.HasPrefix(next.text, "/"):
+		// keeps comments away from division and regex literals
+		return false
+	}
+	return true
+}
+
+// ValidateHTML confirms an obfuscated XSS payload still has the same elements and attributes,
+// and that any JavaScript in it still lexes to the same tokens
+func ValidateHTML(original, obfuscated string) error {
+	a, b := lexHTML(original), lexHTML(obfuscated)
+	if len(a) != len(b) {
+		return fmt.Errorf("HTML node count changed from %d to %d", len(a), len(b))
+	}
+	for i := range a {
+		if err := compareHTMLNodes(a[i], b[i]); err != nil {
+			return fmt.Errorf("HTML node %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func compareHTMLNodes(a, b htmlNode) error {
+	if a.kind != b.kind {
+		return fmt.Errorf("node type changed")
+	}
+	switch a.kind {
+	case htmlScript:
+		return validateJS(a.text, b.text)
+	case htmlTag:
+	default:
+		if a.text != b.text {
+			return fmt.Errorf("text changed from %q to %q", a.text, b.text)
+		}
+		return nil
+	}
+
+	if a.closing != b.closing || a.open != b.open || !strings.EqualFold(a.name, b.name) {
+		return fmt.Errorf("tag <%s> changed to <%s>", a.name, b.name)
+	}
+	if len(a.attrs) != len(b.attrs) {
+		return fmt.Errorf("<%s> attribute count changed from %d to %d", a.name, len(a.attrs), len(b.attrs))
+	}
+	for i, x := range a.attrs {
+		y := b.attrs[i]
+		if !strings.EqualFold(x.name, y.name) || x.quote != y.quote || (x.eq == "") != (y.eq == "") {
+			return fmt.Errorf("attribute %q changed to %q", x.name, y.name)
+		}
+		js, scheme := jsAttribute(x)
+		if !js {
+			if x.value != y.value {
+				return fmt.Errorf("attribute %s value changed", x.name)
+			}
+			continue
+		}
+		if _, yScheme := jsAttribute(y); !strings.EqualFold(scheme, yScheme) {
+			return fmt.Errorf("attribute %s scheme changed", x.name)
+		}
+		if err := validateJS(x.value[len(scheme):], y.value[len(scheme):]); err != nil {
+			return fmt.Errorf("attribute %s: %v", x.name, err)
+		}
+	}
+	return nil
+}
+
+func validateJS(original, obfuscated string) error {
+	toks := lexJS(obfuscated)
+	if unterminatedComment(toks) {
+		return fmt.Errorf("JavaScript block comment is not closed")
+	}
+	return compareTokens("JavaScript", lexJS(original), toks, func(a, b token) bool {
+		return a.text == b.text
+	})
+}
+
+var (
+	posixSpacers = []string{" ", "  ", "\t"}
+	posixQuotes  = []string{"''", `""`, `\`}
+	cmdSpacers   = []string{" ", "  ", "\t"}
+)
+
+// ObfuscateCMDi breaks up command words without changing what the shell runs: POSIX words get
+// empty quotes, backslashes and ${IFS} separators, cmd.exe words get carets and PowerShell words
+// get backticks. Quoted text, variable expansions and operators are left as they are.
+func ObfuscateCMDi(r *rand.Rand, dialect ShellDialect, input string) string {
+	toks := lexShell(dialect, input)
+	var out strings.Builder
+	for i, t := range toks {
+		switch {
+		case t.kind == tokSpace && dialect != ShellPOSIX:
+			out.WriteString(cmdSpacers[r.Intn(len(cmdSpacers))])
+		case t.kind == tokSpace:
+			// ${IFS} only stands in for a space between two words
+			if i > 0 && i+1 < len(toks) && toks[i-1].kind == tokWord && toks[i+1].kind == tokWord && r.Intn(3) == 0 {
+				out.WriteString("${IFS}")
+			} else {
+				out.WriteString(posixSpacers[r.Intn(len(posixSpacers))])
+			}
+		case t.kind == tokWord && dialect == ShellCmd:
+			out.WriteString(obfuscateCmdWord(r, t.text))
+		case t.kind == tokWord && dialect == ShellPowerShell:
+			out.WriteString(obfuscatePowerShellWord(r, t.text))
+		case t.kind == tokWord:
+			out.WriteString(obfuscatePOSIXWord(r, t.text))
+		default:
+			out.WriteString(t.text)
+		}
+	}
+	return out.String()
+}
+
+func obfuscatePOSIXWord(r *rand.Rand, word string) string {
+	if strings.Contains(word, "=") {
+		// assignments stop being assignments once the name is quoted
+		return word
+	}
+	var out strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := quotedEnd(word, i, c == '"')
+			out.WriteString(word[i:end])
+			i = end - 1
+			continue
+		case c == '\\' && i+1 < len(word):
+			out.WriteString(word[i : i+2])
+			i++
+			continue
+		case c == '$':
+			// copy $name, ${...} and $(...) expansions untouched
+			j := i + 1
+			if j < len(word) && (word[j] == '{' || word[j] == '(') {
+				closer := byte('}')
+				if word[j] == '(' {
+					closer = ')'
+				}
+				if end := strings.IndexByte(word[j:], closer); end >= 0 {
+					j += end + 1
+				} else {
+					j = len(word)
+				}
+			} else {
+				for j < len(word) && (isAlnum(word[j]) || word[j] == '_') {
+					j++
+				}
+			}
+			out.WriteString(word[i:j])
+			i = j - 1
+			continue
+		}
+		if i > 0 && isAlnum(c) && isAlnum(word[i-1]) && r.Intn(4) == 0 {
+			out.WriteString(posixQuotes[r.Intn(len(posixQuotes))])
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+func obfuscateCmdWord(r *rand.Rand, word string) string {
+	if strings.ContainsAny(word, "%!") {
+		// carets inside %VAR% or !VAR! break the expansion
+		return word
+	}
+	var out strings.Builder
+	inQuote := false
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c == '"' {
+			inQuote = !inQuote
+		}
+		if !inQuote && i > 0 && word[i-1] != '^' && isAlnum(c) && r.Intn(4) == 0 {
+			out.WriteByte('^')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// powershellEscapes are the letters a backtick turns into control characters (`n, `t, ...)
+const powershellEscapes = "0abefnrtuv"
+
+func obfuscatePowerShellWord(r *rand.Rand, word string) string {
+	if strings.ContainsAny(word, "$[:'\"`") || strings.HasPrefix(word, "-") {
+		// variables, type literals, quoted text and parameter names must stay intact
+		return word
+	}
+	var out strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if i > 0 && isAlnum(c) && !strings.ContainsRune(powershellEscapes, rune(toLower(rune(c)))) && r.Intn(4) == 0 {
+			out.WriteByte('`')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// ValidateShell confirms an obfuscated command still splits into the same operators and
+// words once the shell has removed quoting, escapes and carets
+func ValidateShell(dialect ShellDialect, original, obfuscated string) error {
+	return compareTokens("shell", lexShell(dialect, original), lexShell(dialect, obfuscated), func(a, b token) bool {
+		if a.kind == tokWord {
+			return shellWordValue(dialect, a.text) == shellWordValue(dialect, b.text)
+		}
+		return a.text == b.text
+	})
 }
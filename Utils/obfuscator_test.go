@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// squeeze lowercases s and drops whitespace, inline comments and the given characters. The
+// obfuscators only ever add or vary these, so the squeezed output must equal the squeezed input.
+// It works on plain text and does not use the lexers under test.
+func squeeze(s, drop string) string {
+	s = strings.ReplaceAll(s, "/**/", "")
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if isSpace(byte(c)) && c < 0x80 || strings.ContainsRune(drop, c) {
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+const obfuscationSeeds = 200
+
+func TestObfuscateSQLRoundTrip(t *testing.T) {
+	tests := []struct {
+		input string
+		// keep are single tokens that must survive byte for byte: literals, and comments with their line end
+		keep []string
+	}{
+		{"' OR 'AbC'='AbC'-- -", []string{"'AbC'", "-- -"}},
+		{`1 UNION SELECT 'a--b',"c#D",'it''s'#e`, []string{"'a--b'", `"c#D"`, "'it''s'", "#e"}},
+		{"1 AND SLEEP(5)\n-- x\nAND 1=1", []string{"-- x\n"}},
+		{"1;WAITFOR DELAY '0:0:5'--", []string{"'0:0:5'", "--"}},
+		{"x=N'Ab' OR y=X'4142'", []string{"N'Ab'", "X'4142'"}},
+	}
+	for _, tt := range tests {
+		r := NewRand(1)
+		for i := 0; i < obfuscationSeeds; i++ {
+			out := ObfuscateSQL(r, tt.input)
+			if squeeze(out, "") != squeeze(tt.input, "") {
+				t.Fatalf("ObfuscateSQL(%q) changed the statement: %q", tt.input, out)
+			}
+			for _, k := range tt.keep {
+				if !strings.Contains(out, k) {
+					t.Fatalf("ObfuscateSQL(%q) = %q, lost %q", tt.input, out, k)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateSQL(t *testing.T) {
+	tests := []struct {
+		original, obfuscated string
+		ok                   bool
+	}{
+		{"' OR 1=1-- -", "'/**/oR\t1=1-- -", true},
+		{"1 UNION SELECT NULL", "1/**/UnIoN\n/**/sElEcT NULL", true},
+		{"1 UNION SELECT 'a b'", "1 UNION SELECT 'a/**/b'", false},
+		{"1 UNION SELECT 'Ab'", "1 UNION SELECT 'aB'", false},
+		{"1 UNION SELECT users", "1 UNION SELECT USERS", false},
+		{"1 UNION SELECT 1", "1 UNI/**/ON SELECT 1", false},
+		{"1-- x\nAND 1", "1-- x AND 1", false},
+		{"1 AND 1", "1 /* AND 1", false},
+	}
+	for _, tt := range tests {
+		err := ValidateSQL(tt.original, tt.obfuscated)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateSQL(%q, %q) = %v, want ok=%v", tt.original, tt.obfuscated, err, tt.ok)
+		}
+	}
+}
+
+func TestObfuscateXSSRoundTrip(t *testing.T) {
+	tests := []struct {
+		input string
+		keep  []string
+	}{
+		{"<img src=x onerror=alert(1)>", nil},
+		{`<a title="Hello World" href="javascript:alert('A b')">x</a>`, []string{`"Hello World"`, "'A b'"}},
+		{"<script>var s='</b> X';alert(s)</script>", []string{"'</b> X'"}},
+		{"<svg><desc><![CDATA[<script>alert(1)</script>]]></desc></svg>", []string{"<![CDATA[<script>alert(1)</script>]]>"}},
+		{"<!-- <img src=x> --><b>T</b>", []string{"<!-- <img src=x> -->", ">T<"}},
+	}
+	for _, tt := range tests {
+		r := NewRand(1)
+		for i := 0; i < obfuscationSeeds; i++ {
+			out := ObfuscateXSS(r, tt.input)
+			if squeeze(out, "/") != squeeze(tt.input, "/") {
+				t.Fatalf("ObfuscateXSS(%q) changed the markup: %q", tt.input, out)
+			}
+			for _, k := range tt.keep {
+				if !strings.Contains(out, k) {
+					t.Fatalf("ObfuscateXSS(%q) = %q, lost %q", tt.input, out, k)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateHTML(t *testing.T) {
+	tests := []struct {
+		original, obfuscated string
+		ok                   bool
+	}{
+		{"<img src=x onerror=alert(1)>", "<IMG/sRc=x\tOnErRoR=alert/**/(1)>", true},
+		{"<img src=x onerror=alert(1)>", "<img src=X onerror=alert(1)>", false},
+		{"<img src=x onerror=alert(1)>", "<img src=x/onerror=alert(1)>", false},
+		{`<a href="javascript:alert('a b')">`, `<a href="JaVaScRiPt:alert('a/**/b')">`, false},
+		{"<script>alert(1)</script>", "<script>al/**/ert(1)</script>", false},
+	}
+	for _, tt := range tests {
+		err := ValidateHTML(tt.original, tt.obfuscated)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateHTML(%q, %q) = %v, want ok=%v", tt.original, tt.obfuscated, err, tt.ok)
+		}
+	}
+}
+
+// shArgs returns the words a real POSIX shell splits a simple command into
+func shArgs(t *testing.T, command string) string {
+	out, err := exec.Command("/bin/sh", "-c", `set -- `+command+`; printf '[%s]' "$@"`).Output()
+	if err != nil {
+		t.Fatalf("sh rejected %q: %v", command, err)
+	}
+	return string(out)
+}
+
+func TestObfuscateCMDiPOSIX(t *testing.T) {
+	if _, err := exec.LookPath("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh to compare against")
+	}
+	inputs := []string{
+		"cat /etc/passwd",
+		`echo "a b" 'c d' e\ f`,
+		"ls -la /tmp",
+		"uname -a",
+		`printf %s "$HOME" ${PATH}`,
+		"X=1 env",
+	}
+	for _, in := range inputs {
+		want := shArgs(t, in)
+		r := NewRand(1)
+		for i := 0; i < 50; i++ {
+			out := ObfuscateCMDi(r, ShellPOSIX, in)
+			if got := shArgs(t, out); got != want {
+				t.Fatalf("ObfuscateCMDi(%q) = %q runs %s, want %s", in, out, got, want)
+			}
+		}
+	}
+}
+
+func TestObfuscateCMDiWindows(t *testing.T) {
+	tests := []struct {
+		dialect ShellDialect
+		input   string
+		escape  string
+		keep    []string
+	}{
+		{ShellCmd, "type C:\\Windows\\win.ini", "^", nil},
+		{ShellCmd, `echo "quoted text" & whoami`, "^", []string{`"quoted text"`}},
+		{ShellCmd, "echo %USERNAME%", "^", []string{"%USERNAME%"}},
+		{ShellPowerShell, "Get-Process | Select-Object -First 1", "`", []string{"-First"}},
+		{ShellPowerShell, `Write-Output "a $env:USERNAME"; whoami`, "`", []string{`"a $env:USERNAME"`}},
+	}
+	for _, tt := range tests {
+		r := NewRand(1)
+		for i := 0; i < obfuscationSeeds; i++ {
+			out := ObfuscateCMDi(r, tt.dialect, tt.input)
+			if squeeze(out, tt.escape) != squeeze(tt.input, tt.escape) {
+				t.Fatalf("ObfuscateCMDi(%s, %q) changed the command: %q", tt.dialect, tt.input, out)
+			}
+			for _, k := range tt.keep {
+				if !strings.Contains(out, k) {
+					t.Fatalf("ObfuscateCMDi(%s, %q) = %q, lost %q", tt.dialect, tt.input, out, k)
+				}
+			}
+		}
+	}
+}
+
+func TestValidateShell(t *testing.T) {
+	tests := []struct {
+		dialect              ShellDialect
+		original, obfuscated string
+		ok                   bool
+	}{
+		{ShellPOSIX, "cat /etc/passwd", `c''a\t${IFS}/e"t"c/passwd`, true},
+		{ShellPOSIX, "cat /etc/passwd", "c at /etc/passwd", false},
+		{ShellPOSIX, "id; whoami", "id| whoami", false},
+		{ShellCmd, "whoami & ver", "who^ami  &  v^er", true},
+		{ShellCmd, `echo "ab"`, `echo "a^b"`, false},
+		{ShellPowerShell, "whoami; hostname", "who`ami; host`name", true},
+		{ShellPowerShell, "whoami", "who ami", false},
+	}
+	for _, tt := range tests {
+		err := ValidateShell(tt.dialect, tt.original, tt.obfuscated)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateShell(%s, %q, %q) = %v, want ok=%v", tt.dialect, tt.original, tt.obfuscated, err, tt.ok)
+		}
+	}
+}
@@ -1,12 +1,28 @@
 package utils
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// Metadata records how a payload set was generated so a run can be reproduced
+type Metadata struct {
+	Module string `json:"module"`
+	Seed   int64  `json:"seed"`
+	Count  int    `json:"count"`
+}
+
+// Document wraps generated payloads with their metadata for JSON and console output
+type Document struct {
+	Metadata Metadata    `json:"metadata"`
+	Payloads interface{} `json:"payloads"`
+}
+
 // SaveAsJSON saves any data structure as formatted JSON
 func SaveAsJSON(data interface{}, fileName string) error {
 	content, err := json.MarshalIndent(data, "", "  ")
@@ -40,6 +56,15 @@ func SaveAsTXT(lines []string, fileName string) error {
 	return nil
 }
 
+// SaveAsFile saves binary payloads such as document archives under their own name
+func SaveAsFile(content []byte, fileName string) error {
+	path := filepath.Join("reports", fileName)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fileName, err)
+	}
+	return nil
+}
+
 // PrintToConsole displays payloads to stdout in readable format
 func PrintToConsole(title string, data interface{}) {
 	fmt.Println("====", title, "====")
@@ -50,3 +75,9 @@ func PrintToConsole(title string, data interface{}) {
 	}
 	fmt.Println(string(jsonBytes))
 }
+
+// ShortID returns a stable 8-character ID derived from the given parts
+func ShortID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:4])
+}
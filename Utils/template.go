@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxRangeSize caps a single a..b range so a typo cannot explode the output
+const maxRangeSize = 10000
+
+// placeholderPattern matches {{name}} placeholders; anything else between braces is left alone
+var placeholderPattern = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_]*)\}\}`)
+
+var rangePattern = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)$`)
+
+// Vars maps a placeholder name to every value it should expand to
+type Vars map[string][]string
+
+// Expansion is one rendering of a template together with the value used for each placeholder
+type Expansion struct {
+	Text     string
+	Bindings map[string]string
+}
+
+// Placeholders returns the placeholder names used in a template, in order of first appearance
+func Placeholders(tpl string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(tpl, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// ExpandTemplate renders a template once for every combination (cartesian product) of the
+// values of the placeholders it uses. Placeholders without values are left untouched.
+func ExpandTemplate(tpl string, vars Vars) []Expansion {
+	var names []string
+	for _, name := range Placeholders(tpl) {
+		if len(vars[name]) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	expansions := []Expansion{{Text: tpl, Bindings: map[string]string{}}}
+	for _, name := range names {
+		var next []Expansion
+		for _, e := range expansions {
+			for _, value := range vars[name] {
+				bindings := make(map[string]string, len(e.Bindings)+1)
+				for k, v := range e.Bindings {
+					bindings[k] = v
+				}
+				bindings[name] = value
+				next = append(next, Expansion{Bindings: bindings})
+			}
+		}
+		expansions = next
+	}
+
+	for i := range expansions {
+		bindings := expansions[i].Bindings
+		expansions[i].Text = placeholderPattern.ReplaceAllStringFunc(tpl, func(m string) string {
+			if v, ok := bindings[m[2:len(m)-2]]; ok {
+				return v
+			}
+			return m
+		})
+	}
+	return expansions
+}
+
+// ParseVarValues splits a comma-separated list; items of the form a..b expand to integer ranges
+func ParseVarValues(value string) ([]string, error) {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		m := rangePattern.FindStringSubmatch(item)
+		if m == nil {
+			values = append(values, item)
+			continue
+		}
+
+		from, _ := strconv.Atoi(m[1])
+		to, _ := strconv.Atoi(m[2])
+		step := 1
+		if to < from {
+			step = -1
+		}
+		if (to-from)*step >= maxRangeSize {
+			return nil, fmt.Errorf("range %s is larger than %d values", item, maxRangeSize)
+		}
+		for i := from; ; i += step {
+			values = append(values, strconv.Itoa(i))
+			if i == to {
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+// ParseVar parses a name=value assignment as given to --var
+func ParseVar(assignment string) (string, []string, error) {
+	name, value, ok := strings.Cut(assignment, "=")
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("expected name=value, got %q", assignment)
+	}
+	values, err := ParseVarValues(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, values, nil
+}
+
+// LoadVarsFile reads placeholder values from a JSON object. A string value is parsed like
+// --var (lists and ranges), an array is taken as a literal list and numbers are used as-is.
+func LoadVarsFile(path string) (Vars, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vars file: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse vars file: %v", err)
+	}
+
+	vars := Vars{}
+	for name, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values, err := ParseVarValues(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			vars[name] = values
+		case []interface{}:
+			for _, item := range v {
+				vars[name] = append(vars[name], fmt.Sprint(item))
+			}
+		case float64, bool:
+			vars[name] = []string{fmt.Sprint(v)}
+		default:
+			return nil, fmt.Errorf("%s: unsupported value %v", name, value)
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// runCanary implements `payloadgen canary [--listen addr] [--marker m]`.
+// It serves a local stand-in for SSRF targets and metadata endpoints and logs every request it receives,
+// so payloads from --ssrf can be tested without touching real cloud metadata services.
+func runCanary(args []string) {
+	fs := flag.NewFlagSet("canary", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8089", "Address the canary listens on")
+	marker := fs.String("marker", "pgen7331", "Marker returned in every response body")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "USAGE:\n  ./payloadgen canary [--listen 127.0.0.1:8089] [--marker pgen7331]")
+		fmt.Fprintln(fs.Output(), "\nFLAGS:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("🎯 Canary hit from %s: %s %s (Host: %s)", r.RemoteAddr, r.Method, r.URL.RequestURI(), r.Host)
+		for _, h := range []string{"Metadata-Flavor", "Metadata", "X-aws-ec2-metadata-token-ttl-seconds"} {
+			if v := r.Header.Get(h); v != "" {
+				log.Printf("   ↳ %s: %s", h, v)
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, *marker)
+	})
+
+	server := &http.Server{Addr: *listen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("🐤 Canary listening on http://%s (marker %s)", *listen, *marker)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("❌ Canary stopped: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// runDecode implements `payloadgen decode [--chain a|b] [--output json] <string>`.
+// Without --chain every layer is auto-detected; with no string argument each stdin line is decoded.
+func runDecode(args []string) {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	chain := fs.String("chain", "", "Encoder chain the input was produced with, e.g. \"url|base64|url\" (default: auto-detect)")
+	output := fs.String("output", "console", "Output format: console, json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "USAGE:\n  ./payloadgen decode [--chain \"url|base64\"] [--output json] <encoded string>")
+		fmt.Fprintf(fs.Output(), "\nDecoders: %s\n\nFLAGS:\n", strings.Join(utils.DecoderNames(), ", "))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var inputs []string
+	if fs.NArg() > 0 {
+		inputs = append(inputs, strings.Join(fs.Args(), " "))
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				inputs = append(inputs, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatalf("❌ Failed to read stdin: %v", err)
+		}
+	}
+
+	for _, input := range inputs {
+		var steps []utils.DecodeStep
+		if *chain != "" {
+			var err error
+			steps, err = utils.DecodeChain(*chain, input)
+			if err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+		} else {
+			steps = utils.Analyze(input)
+		}
+		printDecodeSteps(input, steps, *output)
+	}
+}
+
+// printDecodeSteps shows every unwrapping step of one input
+func printDecodeSteps(input string, steps []utils.DecodeStep, format string) {
+	decoded := input
+	if len(steps) > 0 {
+		decoded = steps[len(steps)-1].Output
+	}
+
+	if format == "json" {
+		result := struct {
+			Input   string             `json:"input"`
+			Steps   []utils.DecodeStep `json:"steps"`
+			Decoded string             `json:"decoded"`
+		}{input, steps, decoded}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(result); err != nil {
+			log.Fatalf("❌ Failed to marshal JSON: %v", err)
+		}
+		return
+	}
+
+	fmt.Println("🔎 Input:", input)
+	if len(steps) == 0 {
+		fmt.Println("  No known encoding detected.")
+		return
+	}
+	for i, step := range steps {
+		fmt.Printf("  %d. %-8s → %s\n", i+1, step.Decoder, step.Output)
+	}
+	fmt.Println("✅ Decoded:", decoded)
+}
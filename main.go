@@ -1,10 +1,14 @@
 package main
 
 import (
+	"embed"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/rajaabdullahnasir/Custom-Payload-Generator/modules"
 	"github.com/rajaabdullahnasir/Custom-Payload-Generator/reports"
@@ -12,6 +16,9 @@ import (
 	"github.com/rajaabdullahnasir/Custom-Payload-Generator/zapapi"
 )
 
+//go:embed Payload/*.json
+var payloadFS embed.FS
+
 var helpText = `
 ██████╗ ██████╗ ██████╗ ███████╗██████╗  ██████╗  ██████╗  ██████╗ 
 ██╔══██╗██╔═══██╗██╔══██╗██╔════╝██╔══██╗██╔═══██╗██╔═══██╗██╔════╝ 
@@ -23,38 +30,142 @@ var helpText = `
 Modular Payload Generator Tool by @rajaabdullahnasir
 
 USAGE:
-  ./payloadgen [--xss | --sqli | --cmdi | --zapscan | --generate-report] [flags]
+  ./payloadgen [--<module> | --zapscan | --generate-report] [flags]
+  ./payloadgen decode [--chain "url|base64"] [--output json] <encoded string>
+  ./payloadgen canary [--listen 127.0.0.1:8089] [--marker pgen7331]
 
+MODULES:
+%s
 FLAGS:
-  --xss              Generate XSS payloads
-  --sqli             Generate SQL Injection payloads
-  --cmdi             Generate Command Injection payloads
   --zapscan          Run an automated ZAP scan
   --target           Target URL (required for --zapscan)
   --zap-host         ZAP daemon host (default: localhost)
   --zap-port         ZAP daemon port (default: 8080)
   --zap-key          ZAP API key
   --generate-report  Generate HTML report from existing ZAP results
+  --payload-dir      Directory of user corpora layered over the built-in ones (sqli.json, cmd.json, ...)
+  --corpus           Extra corpus for one module as module=path (repeatable)
+  --count            Number of values substituted for {n} placeholders (default: 2)
+  --var              Placeholder value as name=value; lists (a,b) and ranges (1..5) expand (repeatable)
+  --vars-file        JSON file of placeholder values (marker, callback_host, sleep_seconds, cmd, table, unix_file, windows_file, attribute, charset)
+  --encode           Encoder chains applied to every payload, e.g. "url|base64|url,hex" (default: url,base64,hex,unicode)
+                     Available encoders: %s
+  --context          XSS injection contexts, comma-separated or "all" (default: html)
+                     Available contexts: %s
+  --dbms             SQLi database engines, comma-separated or "all" (default: all)
+                     Available engines: %s
+  --sqli-context     SQLi injection points, comma-separated or "all" (default: single-quote)
+                     Available contexts: %s
+  --sqli-mode        SQLi generation mode (default: payloads): union for column-count and column-type probes,
+                     pairs for matched true/false blind payloads (txt output: id<TAB>true<TAB>false)
+  --max-columns      Widest query probed in --sqli-mode union (default: 10)
+  --shell            CMDi shells, comma-separated or "all" (default: all)
+                     Available shells: %s
+  --cmdi-mode        CMDi generation mode (default: payloads): blind for harmless time-delay and
+                     split-marker echo payloads, driven by sleep_seconds and marker
+  --depth            Deepest ../ sequence for traversal payloads (default: 8)
+  --nosql-mode       NoSQL generation mode (default: payloads): pairs for matched true/false operator payloads
+  --ldapi-mode       LDAP injection generation mode (default: payloads): pairs for matched true/false filters
+  --xpathi-mode      XPath injection generation mode (default: payloads): pairs for matched true/false expressions
+  --ssrf-target      Host[:port] SSRF payloads point at (default: 127.0.0.1:8089, the local canary)
+  --allowed-host     Host an allowlist trusts, used in SSRF and redirect parser-confusion URLs (default: example.com)
+  --attacker-host    Host open-redirect payloads lead to (default: attacker.example). Both hosts are the
+                     {{allowed}} and {{attacker}} placeholders, so --var allowed=a.com,b.com also works,
+                     e.g. --redirect --allowed-host shop.example.com --attacker-host oob.example.net
+  --seed             Seed for obfuscation; the same seed reproduces the same payloads (default: random, recorded in output)
   --output           Output format: json, txt, console
-  --save             Save output to ./reports/
+  --save             Save output to ./reports/; docx and xlsx XXE archives are written alongside
   --clipboard        Copy output to clipboard
   --help             Show help menu
 
 EXAMPLES:
   ./payloadgen --xss --output=json 
   ./payloadgen --cmdi --output=txt 
-  ./payloadgen --sqli
+  ./payloadgen --cmdi --shell bash,powershell
+  ./payloadgen --cmdi --cmdi-mode blind --var sleep_seconds=5,10 --var marker=acme42
+  ./payloadgen --xss --encode "url|base64|url,hex"
+  ./payloadgen --xss --context attr-dq,js-string
+  ./payloadgen --sqli --dbms mssql,postgresql
+  ./payloadgen --sqli --sqli-context numeric,order-by,stacked
+  ./payloadgen --sqli --sqli-mode union --max-columns 6 --dbms oracle
+  ./payloadgen --sqli --sqli-mode pairs --sqli-context single-quote,numeric --output txt
+  ./payloadgen --ssti --output=json
+  ./payloadgen --nosql --var sleep_seconds=3
+  ./payloadgen --nosql --nosql-mode pairs --output txt
+  ./payloadgen --ldapi --ldapi-mode pairs --var attribute=mail,telephoneNumber
+  ./payloadgen --xpathi --count 8 --var charset=a,b,c --output txt
+  ./payloadgen --crlf --var marker=acme42 --encode url
+  ./payloadgen --ssrf --ssrf-target 127.0.0.1:8089 --allowed-host shop.example.com
+  ./payloadgen canary --listen 127.0.0.1:8089
+  ./payloadgen --xxe --var callback_host=oob.example.net --var unix_file=/etc/hostname --save
+  ./payloadgen --ssi --el --var marker=acme42
+  ./payloadgen --traversal --depth 4 --var unix_file=/etc/hosts,/proc/self/environ
+  ./payloadgen --sqli --seed 1337
+  ./payloadgen --sqli --corpus sqli=./team_sqli.json
+  ./payloadgen --sqli --var sleep_seconds=3 --var marker=acme42
   ./payloadgen --zapscan --target=http://example.com --zap-key=abc123
   ./payloadgen --generate-report
+  ./payloadgen decode "JTNDc2NyaXB0JTNF"
 
   Enjoy hacking ethically! 🔐
 `
 
 func main() {
-	// Payload generation flags
-	xss := flag.Bool("xss", false, "Generate XSS payloads")
-	sqli := flag.Bool("sqli", false, "Generate SQLi payloads")
-	cmdi := flag.Bool("cmdi", false, "Generate CMDi payloads")
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "decode", "analyze":
+			runDecode(os.Args[2:])
+			return
+		case "canary":
+			runCanary(os.Args[2:])
+			return
+		}
+	}
+
+	// Payload generation flags, one per registered module
+	selected := map[string]*bool{}
+	for _, m := range modules.All() {
+		selected[m.Name] = flag.Bool(m.Name, false, m.Description)
+	}
+
+	// Corpus options
+	payloadDir := flag.String("payload-dir", "", "Directory of user corpora layered over the built-in ones")
+	corpusFiles := corpusFlag{}
+	flag.Var(corpusFiles, "corpus", "Extra corpus for one module as module=path (repeatable)")
+
+	count := flag.Int("count", 2, "Number of values substituted for {n} placeholders")
+	vars := varsFlag{}
+	flag.Var(vars, "var", "Placeholder value as name=value (repeatable)")
+	varsFile := flag.String("vars-file", "", "JSON file of placeholder values")
+
+	encode := flag.String("encode", "", "Comma-separated encoder chains, e.g. \"url|base64|url,hex\"")
+
+	xssContext := flag.String("context", "", "XSS injection contexts, comma-separated or \"all\"")
+
+	dbms := flag.String("dbms", "", "SQLi database engines, comma-separated or \"all\"")
+
+	sqliContext := flag.String("sqli-context", "", "SQLi injection points, comma-separated or \"all\"")
+
+	sqliMode := flag.String("sqli-mode", modules.SQLiModePayloads, "SQLi generation mode: payloads, union or pairs")
+	maxColumns := flag.Int("max-columns", 10, "Widest query probed in --sqli-mode union")
+
+	shell := flag.String("shell", "", "CMDi shells, comma-separated or \"all\"")
+
+	cmdiMode := flag.String("cmdi-mode", modules.CMDiModePayloads, "CMDi generation mode: payloads or blind")
+
+	depth := flag.Int("depth", 8, "Deepest ../ sequence for traversal payloads")
+
+	nosqlMode := flag.String("nosql-mode", modules.NoSQLModePayloads, "NoSQL generation mode: payloads or pairs")
+
+	ldapiMode := flag.String("ldapi-mode", modules.LDAPiModePayloads, "LDAP injection generation mode: payloads or pairs")
+	xpathiMode := flag.String("xpathi-mode", modules.XPathiModePayloads, "XPath injection generation mode: payloads or pairs")
+
+	ssrfTarget := flag.String("ssrf-target", "", "Host[:port] SSRF payloads point at (default: the local canary)")
+	allowedHost := flag.String("allowed-host", "", "Host an allowlist trusts, used in SSRF and redirect parser-confusion URLs")
+	attackerHost := flag.String("attacker-host", "", "Host open-redirect payloads lead to (default: attacker.example)")
+
+	seed := flag.Int64("seed", 0, "Seed for obfuscation (default: random)")
 
 	// Output options
 	output := flag.String("output", "console", "Output format: json, txt, console")
@@ -75,32 +186,130 @@ func main() {
 	help := flag.Bool("help", false, "Show help menu")
 	flag.Parse()
 
+	anySelected := false
+	for _, on := range selected {
+		anySelected = anySelected || *on
+	}
+
 	// Show help
-	if *help || (!*xss && !*sqli && !*cmdi && !*zapscan && !*generateReport) {
-		fmt.Println(helpText)
+	if *help || (!anySelected && !*zapscan && !*generateReport) {
+		fmt.Printf(helpText, moduleHelp(),
+			strings.Join(utils.EncoderNames(), ", "),
+			strings.Join(modules.XSSContextNames(), ", "),
+			strings.Join(modules.SQLDialectNames(), ", "),
+			strings.Join(modules.SQLContextNames(), ", "),
+			strings.Join(modules.CMDShellNames(), ", "))
 		return
 	}
 
-	// Payload Generator
-	if *xss {
-		payloads, err := modules.GenerateXSSPayloads()
+	corpus, err := fs.Sub(payloadFS, "Payload")
+	if err != nil {
+		log.Fatalf("❌ Failed to open embedded corpora: %v", err)
+	}
+	templateVars := utils.Vars{}
+	if *varsFile != "" {
+		templateVars, err = utils.LoadVarsFile(*varsFile)
 		if err != nil {
-			log.Fatalf("❌ Failed to generate XSS payloads: %v", err)
+			log.Fatalf("❌ %v", err)
 		}
-		handleOutput("xss_payloads", payloads, *output, *save, *clip)
+	}
+	for name, values := range vars {
+		templateVars[name] = values
 	}
 
-	if *sqli {
-		payloads, err := modules.GenerateSQLiPayloads()
+	var pipelines []utils.Pipeline
+	if *encode != "" {
+		for _, spec := range strings.Split(*encode, ",") {
+			p, err := utils.ParsePipeline(spec)
+			if err != nil {
+				log.Fatalf("❌ Invalid --encode: %v", err)
+			}
+			pipelines = append(pipelines, p)
+		}
+	}
+
+	var xssContexts []string
+	if *xssContext != "" {
+		xssContexts, err = modules.ParseXSSContexts(*xssContext)
 		if err != nil {
-			log.Fatalf("❌ Failed to generate SQLi payloads: %v", err)
+			log.Fatalf("❌ Invalid --context: %v", err)
 		}
-		handleOutput("sqli_payloads", payloads, *output, *save, *clip)
 	}
 
-	if *cmdi {
-		payloads := modules.GenerateCMDiPayloads()
-		handleOutput("cmdi_payloads", payloads, *output, *save, *clip)
+	var dbmsNames []string
+	if *dbms != "" {
+		dbmsNames, err = modules.ParseDBMS(*dbms)
+		if err != nil {
+			log.Fatalf("❌ Invalid --dbms: %v", err)
+		}
+	}
+
+	var sqliContexts []string
+	if *sqliContext != "" {
+		sqliContexts, err = modules.ParseSQLContexts(*sqliContext)
+		if err != nil {
+			log.Fatalf("❌ Invalid --sqli-context: %v", err)
+		}
+	}
+
+	var shells []string
+	if *shell != "" {
+		shells, err = modules.ParseShells(*shell)
+		if err != nil {
+			log.Fatalf("❌ Invalid --shell: %v", err)
+		}
+	}
+
+	validateMode("sqli-mode", *sqliMode, modules.SQLiModes)
+	validateMode("cmdi-mode", *cmdiMode, modules.CMDiModes)
+	validateMode("nosql-mode", *nosqlMode, modules.NoSQLModes)
+	validateMode("ldapi-mode", *ldapiMode, modules.LDAPiModes)
+	validateMode("xpathi-mode", *xpathiMode, modules.XPathiModes)
+
+	// An explicit --seed 0 is honoured; only an absent flag picks a random seed
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) {
+		seedSet = seedSet || f.Name == "seed"
+	})
+	if !seedSet {
+		*seed = time.Now().UnixNano()
+	}
+
+	opts := modules.Options{
+		Corpus:       corpus,
+		PayloadDir:   *payloadDir,
+		CorpusFiles:  corpusFiles,
+		Count:        *count,
+		Vars:         templateVars,
+		Encoders:     pipelines,
+		XSSContexts:  xssContexts,
+		DBMS:         dbmsNames,
+		SQLiContexts: sqliContexts,
+		SQLiMode:     *sqliMode,
+		MaxColumns:   *maxColumns,
+		Shells:       shells,
+		CMDiMode:     *cmdiMode,
+		Depth:        *depth,
+		NoSQLMode:    *nosqlMode,
+		LDAPiMode:    *ldapiMode,
+		XPathiMode:   *xpathiMode,
+		SSRFTarget:   *ssrfTarget,
+		AllowedHost:  *allowedHost,
+		AttackerHost: *attackerHost,
+		Seed:         *seed,
+	}
+
+	// Payload Generator
+	for _, m := range modules.All() {
+		if !*selected[m.Name] {
+			continue
+		}
+		payloads, err := m.Generate(opts)
+		if err != nil {
+			log.Fatalf("❌ Failed to generate %s payloads: %v", m.Name, err)
+		}
+		meta := utils.Metadata{Module: m.Name, Seed: *seed, Count: len(payloads)}
+		handleOutput(m.Name+"_payloads", meta, payloads, *output, *save, *clip)
 	}
 
 	// ZAP Scanner
@@ -142,18 +351,82 @@ func main() {
 	}
 }
 
-func handleOutput(name string, payloads interface{}, format string, save bool, clip bool) {
+// corpusFlag collects repeated --corpus module=path values
+type corpusFlag map[string]string
+
+func (c corpusFlag) String() string {
+	var pairs []string
+	for name, path := range c {
+		pairs = append(pairs, name+"="+path)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (c corpusFlag) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected module=path, got %q", value)
+	}
+	if _, known := modules.Lookup(name); !known {
+		return fmt.Errorf("unknown module %q", name)
+	}
+	c[name] = path
+	return nil
+}
+
+// varsFlag collects repeated --var name=value placeholder assignments
+type varsFlag utils.Vars
+
+func (v varsFlag) String() string {
+	var pairs []string
+	for name, values := range v {
+		pairs = append(pairs, name+"="+strings.Join(values, ","))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (v varsFlag) Set(value string) error {
+	name, values, err := utils.ParseVar(value)
+	if err != nil {
+		return err
+	}
+	v[name] = values
+	return nil
+}
+
+// moduleHelp lists the registered modules for the help menu
+func moduleHelp() string {
+	var b strings.Builder
+	for _, m := range modules.All() {
+		fmt.Fprintf(&b, "  --%-17s%s\n", m.Name, m.Description)
+	}
+	return b.String()
+}
+
+// validateMode exits if value is not one of the modes a --*-mode flag accepts
+func validateMode(name, value string, allowed []string) {
+	for _, mode := range allowed {
+		if mode == value {
+			return
+		}
+	}
+	log.Fatalf("❌ Invalid --%s %q (available: %s)", name, value, strings.Join(allowed, ", "))
+}
+
+func handleOutput(name string, meta utils.Metadata, payloads []modules.Payload, format string, save bool, clip bool) {
+	doc := utils.Document{Metadata: meta, Payloads: payloads}
+
 	switch format {
 	case "json":
 		if save {
-			err := utils.SaveAsJSON(payloads, name)
+			err := utils.SaveAsJSON(doc, name)
 			if err != nil {
 				log.Printf("⚠️ Could not save JSON: %v", err)
 			} else {
 				fmt.Printf("✅ Saved %s.json in /reports/\n", name)
 			}
 		} else {
-			utils.PrintToConsole(name, payloads)
+			utils.PrintToConsole(name, doc)
 		}
 	case "txt":
 		lines := flattenPayloads(payloads)
@@ -168,12 +441,16 @@ func handleOutput(name string, payloads interface{}, format string, save bool, c
 			utils.PrintToConsole(name, lines)
 		}
 	case "console":
-		utils.PrintToConsole(name, payloads)
+		utils.PrintToConsole(name, doc)
 	default:
 		fmt.Println("❌ Invalid output format. Use json, txt, or console.")
 		os.Exit(1)
 	}
 
+	if save {
+		saveFiles(payloads)
+	}
+
 	if clip {
 		lines := flattenPayloads(payloads)
 		if len(lines) > 0 {
@@ -187,23 +464,35 @@ func handleOutput(name string, payloads interface{}, format string, save bool, c
 	}
 }
 
-func flattenPayloads(data interface{}) []string {
-	var lines []string
-	switch v := data.(type) {
-	case []modules.XSSPayload:
-		for _, p := range v {
-			lines = append(lines, p.Payload)
+// saveFiles writes the payloads that are delivered as files, such as docx and xlsx archives, next to the report
+func saveFiles(payloads []modules.Payload) {
+	for _, p := range payloads {
+		f, ok := p.(modules.File)
+		if !ok {
+			continue
 		}
-	case []modules.SQLiPayload:
-		for _, p := range v {
-			lines = append(lines, p.Payload)
+		name, content := f.File()
+		if name == "" {
+			continue
 		}
-	case []modules.CMDPayload:
-		for _, p := range v {
-			lines = append(lines, p.Original)
+		if err := utils.SaveAsFile(content, name); err != nil {
+			log.Printf("⚠️ Could not save file: %v", err)
+		} else {
+			fmt.Printf("✅ Saved %s in /reports/\n", name)
 		}
-	default:
-		lines = append(lines, fmt.Sprintf("%v", v))
+	}
+}
+
+// flattenPayloads renders one line per payload; boolean pairs keep their pairing as id, true and false variants
+func flattenPayloads(payloads []modules.Payload) []string {
+	var lines []string
+	for _, p := range payloads {
+		if paired, ok := p.(modules.Paired); ok && paired.Pair() != nil {
+			pair := paired.Pair()
+			lines = append(lines, pair.PairID+"\t"+pair.TrueVariant+"\t"+pair.FalseVariant)
+			continue
+		}
+		lines = append(lines, p.Text())
 	}
 	return lines
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/modules"
+	"github.com/rajaabdullahnasir/Custom-Payload-Generator/utils"
+)
+
+// TestTXTOutputOneLinePerPayload saves every module's payloads as txt and checks that each payload
+// takes exactly one line, so payloads with raw line breaks (such as CRLF injections) stay intact
+func TestTXTOutputOneLinePerPayload(t *testing.T) {
+	corpus, err := fs.Sub(payloadFS, "Payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "reports"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, m := range modules.All() {
+		payloads, err := m.Generate(modules.Options{Corpus: corpus, Seed: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", m.Name, err)
+		}
+		if err := utils.SaveAsTXT(flattenPayloads(payloads), m.Name); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(filepath.Join("reports", m.Name+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := strings.Count(string(content), "\n"); n != len(payloads) {
+			t.Errorf("%s: %d payloads written as %d lines", m.Name, len(payloads), n)
+		}
+		// a lone \r also ends a line for most readers
+		if strings.Contains(string(content), "\r") {
+			t.Errorf("%s: txt output contains a raw carriage return", m.Name)
+		}
+	}
+}